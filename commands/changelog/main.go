@@ -6,11 +6,11 @@
 package changelog
 
 import (
+	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"os"
-	"path/filepath"
 
+	"github.com/Masterminds/semver"
 	"github.com/ShogunPanda/impacca/utils"
 	"github.com/ShogunPanda/tempera"
 	"github.com/spf13/cobra"
@@ -30,27 +30,63 @@ func InitCLI() *cobra.Command {
 		Args: cobra.ExactArgs(1), Run: showVersion,
 	})
 
-	cmd.AddCommand(&cobra.Command{
+	saveCmd := &cobra.Command{
 		Use: "save <version> [changes...]", Aliases: []string{"s"}, Short: "Insert all changes since the last version in the CHANGELOG.md file.",
 		Args: cobra.MinimumNArgs(1), Run: saveChanges,
-	})
+	}
+	saveCmd.Flags().StringP("preid", "i", "", "The identifier of prerelease versions, like beta.")
+	cmd.AddCommand(saveCmd)
 
 	cmd.AddCommand(&cobra.Command{
+		Use: "unreleased [changes...]", Aliases: []string{"u"},
+		Short: "Insert all changes since the last version in the Unreleased section of the CHANGELOG.md file (Keep a Changelog format only).",
+		Run:   saveUnreleased,
+	})
+
+	lintCmd := &cobra.Command{
+		Use: "lint", Aliases: []string{"k"}, Short: "Validates the CHANGELOG.md file against GIT versions. Exits with a non-zero code if problems are found.",
+		Run: lint,
+	}
+	lintCmd.Flags().StringP("format", "f", "json", "The report format. Can be \"json\" or \"text\".")
+	cmd.AddCommand(lintCmd)
+
+	regenerateCmd := &cobra.Command{
 		Use: "regenerate", Aliases: []string{"r"}, Short: "Regenerates the entire CHANGELOG.md file, EXCLUDING changes since the last version.",
 		Run: regenerate,
-	})
+	}
+	regenerateCmd.Flags().BoolP("force", "f", false, "Also regenerate versions already present in the CHANGELOG.md file, discarding manual changes.")
+	cmd.AddCommand(regenerateCmd)
 
 	return cmd
 }
 
+func printChanges(changes []utils.Change) {
+	for _, group := range utils.GroupChanges(changes) {
+		if group.Title != "" {
+			fmt.Printf(tempera.ColorizeTemplate("\n\u0020\u0020\u0020{secondary}%s{-}\n"), group.Title)
+		}
+
+		for _, change := range group.Changes {
+			prefix := change.Prefix()
+
+			if group.Title != "" || prefix == "" {
+				fmt.Printf(
+					tempera.ColorizeTemplate("\u0020\u0020\u0020* {primary}%s{-} ({secondary}%s{-})\n"),
+					utils.FormatChangeEntry(change, group.Title != ""), change.Hash,
+				)
+			} else {
+				fmt.Printf(tempera.ColorizeTemplate("\u0020\u0020\u0020* {gray}%s{-}: {primary}%s{-} ({secondary}%s{-})\n"), prefix, change.Message, change.Hash)
+			}
+		}
+	}
+}
+
 func showChanges(cmd *cobra.Command, args []string) {
 	currentVersion := utils.GetCurrentVersion()
-	changes := utils.ListChanges(currentVersion.String(), "")
-	utils.Info("Found {secondary}%d{-} change(s) since release {secondary}%s{-}:", len(changes), currentVersion)
+	changes := utils.ListChanges(utils.FormatVersion(currentVersion), "")
+	utils.Info("Found {secondary}%d{-} change(s) since release {secondary}%s{-}:", len(changes), utils.FormatVersion(currentVersion))
 
-	for _, change := range changes {
-		fmt.Printf(tempera.ColorizeTemplate("\u0020\u0020\u0020* {gray}%s{-}: {primary}%s{-} ({secondary}%s{-})\n"), change.Type, change.Message, change.Hash)
-	}
+	printChanges(changes)
 }
 
 func showVersion(cmd *cobra.Command, args []string) {
@@ -59,7 +95,7 @@ func showVersion(cmd *cobra.Command, args []string) {
 
 	currentIndex := -1
 	for i, v := range versions {
-		if v.String() == currentVersion {
+		if utils.FormatVersion(v) == currentVersion {
 			currentIndex = i
 			break
 		}
@@ -70,10 +106,10 @@ func showVersion(cmd *cobra.Command, args []string) {
 	if currentIndex > 0 {
 		previousVersion := versions[currentIndex-1]
 
-		changes = utils.ListChanges(currentVersion, previousVersion.String())
+		changes = utils.ListChanges(currentVersion, utils.FormatVersion(previousVersion))
 		utils.Info(
 			"Found {secondary}%d{-} change(s) between release {secondary}%s{-} and {secondary}%s{-}:",
-			len(changes), previousVersion, currentVersion,
+			len(changes), utils.FormatVersion(previousVersion), currentVersion,
 		)
 	} else {
 		changes = utils.ListChanges(currentVersion, utils.GetFirstCommitHash())
@@ -84,24 +120,23 @@ func showVersion(cmd *cobra.Command, args []string) {
 		)
 	}
 
-	for _, change := range changes {
-		fmt.Printf(tempera.ColorizeTemplate("\u0020\u0020\u0020* {gray}%s{-}: {primary}%s{-} ({secondary}%s{-})\n"), change.Type, change.Message, change.Hash)
-	}
+	printChanges(changes)
 }
 
 func saveChanges(cmd *cobra.Command, args []string) {
 	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	preid, _ := cmd.Flags().GetString("preid")
 	rawChanges := args[1:]
 	currentVersion := utils.GetCurrentVersion()
-	newVersion := utils.ChangeVersion(currentVersion, args[0])
+	newVersion := utils.ChangeVersion(currentVersion, args[0], preid)
 
 	changes := make([]utils.Change, 0)
 
 	if len(rawChanges) == 0 {
-		changes = utils.ListChanges(currentVersion.String(), "")
+		changes = utils.ListChanges(utils.FormatVersion(currentVersion), "")
 	} else {
 		for _, c := range rawChanges {
-			changes = append(changes, utils.Change{Hash: "", Message: c})
+			changes = append(changes, utils.ParseCommitMessage("", c))
 		}
 	}
 
@@ -113,32 +148,111 @@ func saveChanges(cmd *cobra.Command, args []string) {
 	utils.Complete()
 }
 
+func saveUnreleased(cmd *cobra.Command, args []string) {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	if !utils.IsKeepAChangelog() {
+		utils.Fatal("The Unreleased section is only supported when using the {errorPrimary}keepachangelog{-} format.")
+	}
+
+	changes := make([]utils.Change, 0)
+
+	if len(args) == 0 {
+		changes = utils.ListChanges(utils.FormatVersion(utils.GetCurrentVersion()), "")
+	} else {
+		for _, c := range args {
+			changes = append(changes, utils.ParseCommitMessage("", c))
+		}
+	}
+
+	if utils.NotifyExecution(dryRun, "Will add", "Adding", " {primary}%d{-} entries to the Unreleased section of the CHANGELOG.md file ...", len(changes)) {
+		utils.WriteChangelog(utils.FormatKeepAChangelogUnreleased(utils.ReadChangelog(), changes))
+	}
+}
+
+func lint(cmd *cobra.Command, args []string) {
+	format, _ := cmd.Flags().GetString("format")
+	problems := utils.LintChangelog(utils.ReadParsedChangelog(), utils.GetVersions())
+
+	switch format {
+	case "json":
+		report, _ := json.MarshalIndent(map[string]interface{}{"valid": len(problems) == 0, "problems": problems}, "", "  ")
+		fmt.Println(string(report))
+	case "text":
+		if len(problems) == 0 {
+			utils.Success("The CHANGELOG.md file is valid.")
+		}
+
+		for _, problem := range problems {
+			if problem.Line > 0 {
+				utils.Fail("{errorPrimary}%s{-} (line {errorPrimary}%d{-}): %s", problem.Rule, problem.Line, problem.Message)
+			} else {
+				utils.Fail("{errorPrimary}%s{-}: %s", problem.Rule, problem.Message)
+			}
+		}
+	default:
+		utils.Fatal("Unsupported report format {errorPrimary}%s{-}.", format)
+	}
+
+	if len(problems) > 0 {
+		os.Exit(1)
+	}
+}
+
 func regenerate(cmd *cobra.Command, args []string) {
-	cwd, _ := os.Getwd()
 	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	force, _ := cmd.Flags().GetBool("force")
 	versions := utils.GetVersions()
+	existing := utils.ReadParsedChangelog()
+	versionsChanges := make([]utils.VersionChanges, 0)
 
-	changelog := ""
 	for i, version := range versions {
-		previousVersion := ""
+		// Unless forced, preserve versions already in the file
+		if !force && existing.Find(utils.FormatVersion(version)) != nil {
+			continue
+		}
+
+		var previousVersion *semver.Version
+		previousReference := ""
 
 		if i > 0 {
-			previousVersion = versions[i-1].String()
+			previousVersion = versions[i-1]
+			previousReference = utils.FormatVersion(previousVersion)
 		} else {
-			previousVersion = utils.GetFirstCommitHash()
+			previousReference = utils.GetFirstCommitHash()
 		}
 
-		date := utils.GetVersionDate(version)
-		changes := utils.ListChanges(version.String(), previousVersion)
-		changelog = utils.FormatChanges(changelog, version, changes, date)
+		versionsChanges = append(versionsChanges, utils.VersionChanges{
+			Version:         version,
+			PreviousVersion: previousVersion,
+			Date:            utils.GetVersionDate(version),
+			Changes:         utils.ListChanges(utils.FormatVersion(version), previousReference),
+		})
 	}
 
-	if utils.NotifyExecution(dryRun, "Will rewrite", "Rewriting", " CHANGELOG.md file ...") {
-		// Save the new file
-		err := ioutil.WriteFile(filepath.Join(cwd, "CHANGELOG.md"), []byte(changelog), 0644)
+	changelog := ""
 
-		if err != nil {
-			utils.Fatal("Cannot update file {errorPrimary}CHANGELOG.md{-}: {errorPrimary}%s{-}", err.Error())
+	if utils.IsKeepAChangelog() {
+		changelog = utils.RegenerateKeepAChangelog(existing.String(), versionsChanges, force)
+	} else if force {
+		for _, versionChanges := range versionsChanges {
+			changelog = utils.FormatChanges(
+				changelog, versionChanges.Version, versionChanges.PreviousVersion, versionChanges.Changes, versionChanges.Date,
+			)
 		}
+	} else {
+		generated := make(map[string]string)
+
+		for _, versionChanges := range versionsChanges {
+			generated[versionChanges.Version.String()] = utils.FormatChanges(
+				"", versionChanges.Version, versionChanges.PreviousVersion, versionChanges.Changes, versionChanges.Date,
+			)
+		}
+
+		changelog = utils.MergeChangelogSections(existing, generated, versions)
+	}
+
+	if utils.NotifyExecution(dryRun, "Will rewrite", "Rewriting", " CHANGELOG.md file ...") {
+		utils.WriteChangelog(changelog)
 	}
 }
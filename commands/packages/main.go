@@ -0,0 +1,39 @@
+/*
+ * This file is part of impacca. Copyright (C) 2013 and above Shogun <shogun@cowtech.it>.
+ * Licensed under the MIT license, which can be found at https://choosealicense.com/licenses/mit.
+ */
+
+package packages
+
+import (
+	"fmt"
+
+	"github.com/ShogunPanda/impacca/utils"
+	"github.com/ShogunPanda/tempera"
+	"github.com/spf13/cobra"
+)
+
+// InitCLI initializes the CLI
+func InitCLI() *cobra.Command {
+	return &cobra.Command{
+		Use: "packages", Aliases: []string{"workspaces", "w"}, Short: "Show all the packages of a monorepo, in publishing order.", Run: listPackages,
+	}
+}
+
+func listPackages(cmd *cobra.Command, args []string) {
+	packages := utils.SortPackages(utils.DiscoverPackages())
+
+	utils.Info("Found {secondary}%d{-} package(s):", len(packages))
+
+	for _, pkg := range packages {
+		utils.SelectPackage(pkg)
+
+		currentVersion := utils.GetCurrentVersion()
+		changes := utils.ListChanges(utils.FormatVersion(currentVersion), "")
+
+		fmt.Printf(
+			tempera.ColorizeTemplate("\u0020\u0020\u0020* {primary}%s{-} ({secondary}%s{-}): %s, %d change(s)\n"),
+			pkg.Name, pkg.Path, utils.VersionTag(utils.FormatVersion(currentVersion)), len(changes),
+		)
+	}
+}
@@ -6,11 +6,8 @@
 package publish
 
 import (
-	"fmt"
-	"os"
-	"strings"
-
 	"github.com/Masterminds/semver"
+	"github.com/ShogunPanda/impacca/configuration"
 	"github.com/ShogunPanda/impacca/utils"
 	"github.com/spf13/cobra"
 )
@@ -23,63 +20,36 @@ func InitCLI() *cobra.Command {
 
 	cmd.Flags().BoolP("private", "p", false, "Use private scope when possible.")
 	cmd.Flags().StringP("remote", "r", "origin", "The git remote name.")
-	cmd.Flags().StringP("token", "t", "", "The GitHub API token.")
+	cmd.Flags().StringP("token", "t", "", "The GitHub, GitLab or Gitea API token.")
 	cmd.Flags().BoolP("skip-changelog", "c", false, "Do not update the CHANGELOG.md file.")
-	cmd.Flags().BoolP("skip-release", "R", false, "Do not update GitHub releases.")
+	cmd.Flags().BoolP("skip-release", "R", false, "Do not update GitHub, GitLab or Gitea releases.")
+	cmd.Flags().BoolP("from-changelog", "l", false, "Use the version section of the CHANGELOG.md file as release body, when present.")
+	cmd.Flags().StringP("preid", "i", "", "The identifier of prerelease versions, like beta. With auto, a prerelease of the detected change is published.")
 
 	return cmd
 }
 
-func detectNewVersion(currentVersion *semver.Version) *semver.Version {
-	changes := utils.ListChanges(currentVersion.String(), "")
-	newVersion := "patch"
+func detectNewVersion(currentVersion *semver.Version, preid string) *semver.Version {
+	changes := utils.ListChanges(utils.FormatVersion(currentVersion), "")
 
 	if len(changes) == 0 {
 		utils.Fatal("Cannot detect the new version: no changes found.")
 	}
 
-	for _, change := range changes {
-		if strings.HasSuffix(change.Type, "!") || strings.Index(change.Message, "\nBREAKING CHANGE: ") != -1 {
-			newVersion = "major"
-			break
-		} else if change.Hash == "feat" {
-			newVersion = "minor"
-		}
-	}
+	newVersion, bump, _ := utils.DetectNextVersion(currentVersion, changes)
 
-	return utils.ChangeVersion(currentVersion, newVersion)
-}
-
-func publishNpmPackage(newVersion, currentVersion *semver.Version, private, dryRun bool) {
-	access := "public"
-
-	if private {
-		access = "restricted"
+	if newVersion == nil {
+		utils.Fatal("Cannot detect the new version: no changes require a release.")
 	}
 
-	utils.NotifyStep(dryRun, "", "Will update", "Updating", " the version to {primary}%s{-} ...", newVersion.String())
-	utils.UpdateNpmVersion(newVersion, currentVersion, true, false, dryRun)
-
-	if utils.NotifyExecution(dryRun, "Will execute", "Executing", ": {primary}npm publish --access %s{-} ...", access) {
-		result := utils.Execute(true, "npm", "publish", fmt.Sprintf("--access %s", access))
-		result.Verify("npm", "Cannot publish the package")
+	if preid != "" {
+		newVersion = utils.ChangeVersion(currentVersion, utils.PrereleaseChange(currentVersion, bump), preid)
 	}
-}
-
-func publishGem(newVersion, currentVersion *semver.Version, dryRun bool) {
-	utils.NotifyStep(dryRun, "", "Will update", "Updating", " the version to {primary}%s{-} ...", newVersion.String())
-	utils.UpdateGemVersion(newVersion, currentVersion, true, false, dryRun)
 
-	if utils.NotifyExecution(dryRun, "Will execute", "Executing", ": {primary}rake release{-} ...") {
-		result := utils.Execute(true, "rake", "release")
-		result.Verify("rake", "Cannot publish the gem")
-	}
+	return newVersion
 }
 
-func publishPlain(newVersion, currentVersion *semver.Version, dryRun bool) {
-	utils.NotifyStep(dryRun, "", "Will update", "Updating", " the version to {primary}%s{-} ...", newVersion.String())
-	utils.UpdateVersion(newVersion, currentVersion, dryRun)
-
+func pushVersion(dryRun bool) {
 	if utils.NotifyExecution(dryRun, "Will push", "Pushing", " commits ...") {
 		result := utils.Execute(true, "git", "push")
 		result.Verify("git", "Cannot push commits")
@@ -91,63 +61,140 @@ func publishPlain(newVersion, currentVersion *semver.Version, dryRun bool) {
 	}
 }
 
-func publish(cmd *cobra.Command, args []string) {
-	dryRun, _ := cmd.Flags().GetBool("dry-run")
-	skipChangelog, _ := cmd.Flags().GetBool("skip-changelog")
-	skipRelease, _ := cmd.Flags().GetBool("skip-release")
-	private, _ := cmd.Flags().GetBool("private")
-	remote, _ := cmd.Flags().GetString("remote")
-	repository := utils.DetectGithubRepository(remote, true)
-	token, _ := cmd.Flags().GetString("token")
+type publishOptions struct {
+	dryRun        bool
+	skipChangelog bool
+	skipRelease   bool
+	private       bool
+	fromChangelog bool
+	preid         string
+	remote        string
+	repository    *utils.Repository
+	token         string
+}
 
-	rawChanges := args[1:]
-	currentVersion := utils.GetCurrentVersion()
-	var newVersion *semver.Version
+func publishVersion(options publishOptions, newVersion, currentVersion *semver.Version, rawChanges []string) {
+	dryRun := options.dryRun
 
-	if args[0] == "auto" {
-		newVersion = detectNewVersion(currentVersion)
-	} else {
-		newVersion = utils.ChangeVersion(currentVersion, args[0])
+	if !options.skipChangelog && utils.NotifyStep(dryRun, "", "Will update", "Updating", " CHANGELOG.md file ...") {
+		changes := make([]utils.Change, 0)
+
+		if len(rawChanges) == 0 {
+			changes = utils.ListChanges(utils.FormatVersion(currentVersion), "")
+		} else {
+			for _, c := range rawChanges {
+				changes = append(changes, utils.ParseCommitMessage("", c))
+			}
+		}
+
+		utils.SaveChanges(newVersion, currentVersion, changes, dryRun)
 	}
 
-	if !dryRun {
-		utils.GitMustBeClean("perform the publishing")
+	manager := utils.DetectPackageManager()
+
+	utils.NotifyStep(dryRun, "", "Will update", "Updating", " the version to {primary}%s{-} ...", utils.FormatVersion(newVersion))
+	utils.ReleaseVersion(manager, newVersion, currentVersion, dryRun)
+
+	// The release version is published before going back to a development version
+	manager.Publish(newVersion, utils.PublishOptions{Private: options.private, DryRun: dryRun})
+	utils.PrepareDevelopmentVersion(manager, newVersion, dryRun)
+	pushVersion(dryRun)
+
+	// The container image is built from the tagged version
+	utils.BuildContainerImage(newVersion, dryRun)
+
+	// Now edit the GitHub, GitLab or Gitea release, if applicable
+	if !options.skipRelease && options.repository != nil {
+		release := utils.SaveRelease(newVersion, options.repository, options.token, options.fromChangelog, dryRun)
+		utils.UploadReleaseAssets(options.repository, options.token, release, dryRun)
 	}
+}
+
+func publishPackages(options publishOptions, change string) {
+	// Packages are published after the packages they depend on
+	for _, pkg := range utils.SortPackages(utils.DiscoverPackages()) {
+		utils.SelectPackage(pkg)
+
+		currentVersion := utils.GetCurrentVersion()
+		changes := utils.ListChanges(utils.FormatVersion(currentVersion), "")
+
+		if len(changes) == 0 {
+			utils.Info("Skipping package {primary}%s{-} as it has no changes.", pkg.Name)
+			continue
+		}
+
+		var newVersion *semver.Version
 
-	if token == "" {
-		token = os.Getenv("IMPACCA_GITHUB_TOKEN")
+		if change == "auto" {
+			var bump string
+
+			if newVersion, bump, _ = utils.DetectNextVersion(currentVersion, changes); newVersion == nil {
+				utils.Info("Skipping package {primary}%s{-} as no changes require a release.", pkg.Name)
+				continue
+			} else if options.preid != "" {
+				newVersion = utils.ChangeVersion(currentVersion, utils.PrereleaseChange(currentVersion, bump), options.preid)
+			}
+		} else {
+			newVersion = utils.ChangeVersion(currentVersion, change, options.preid)
+		}
+
+		utils.Info("Publishing package {primary}%s{-} ...", pkg.Name)
+		publishVersion(options, newVersion, currentVersion, []string{})
 	}
+}
 
-	if !skipRelease && repository != "" && token == "" {
-		utils.Fatal("In order to publish with a related GitHub release, you must provide a GitHub API token.")
+func publish(cmd *cobra.Command, args []string) {
+	options := publishOptions{fromChangelog: configuration.Current.Release.FromChangelog}
+	options.dryRun, _ = cmd.Flags().GetBool("dry-run")
+	options.skipChangelog, _ = cmd.Flags().GetBool("skip-changelog")
+	options.skipRelease, _ = cmd.Flags().GetBool("skip-release")
+	options.private, _ = cmd.Flags().GetBool("private")
+	options.preid, _ = cmd.Flags().GetString("preid")
+	options.remote, _ = cmd.Flags().GetString("remote")
+	options.repository = utils.DetectRepository(options.remote, true)
+	options.token, _ = cmd.Flags().GetString("token")
+
+	if cmd.Flags().Changed("from-changelog") {
+		options.fromChangelog, _ = cmd.Flags().GetBool("from-changelog")
 	}
 
-	if !skipChangelog && utils.NotifyStep(dryRun, "", "Will update", "Updating", " CHANGELOG.md file ...") {
-		changes := make([]utils.Change, 0)
+	// Without a selected package, a monorepo publishes all changed packages
+	monorepo := utils.IsMonorepo() && utils.CurrentPackage == nil
+	rawChanges := args[1:]
+	var newVersion, currentVersion *semver.Version
 
-		if len(rawChanges) == 0 {
-			changes = utils.ListChanges(currentVersion.String(), "")
+	if monorepo {
+		if len(rawChanges) > 0 {
+			utils.Fatal("Changes cannot be specified when publishing all the packages of a monorepo.")
+		}
+	} else {
+		currentVersion = utils.GetCurrentVersion()
+
+		if args[0] == "auto" {
+			newVersion = detectNewVersion(currentVersion, options.preid)
 		} else {
-			for _, c := range rawChanges {
-				changes = append(changes, utils.Change{Hash: "", Message: c})
-			}
+			newVersion = utils.ChangeVersion(currentVersion, args[0], options.preid)
 		}
+	}
 
-		utils.SaveChanges(newVersion, currentVersion, changes, dryRun)
+	if !options.dryRun {
+		utils.GitMustBeClean("perform the publishing")
 	}
 
-	switch utils.DetectPackageManager() {
-	case utils.NpmPackageManager:
-		publishNpmPackage(newVersion, currentVersion, private, dryRun)
-	case utils.GemPackageManager:
-		publishGem(newVersion, currentVersion, dryRun)
-	default:
-		publishPlain(newVersion, currentVersion, dryRun)
+	if options.repository != nil {
+		options.token = options.repository.Token(options.token)
 	}
 
-	// Now edit the Github release, if applicable
-	if !skipRelease && repository != "" {
-		utils.SaveRelease(newVersion, repository, remote, token, dryRun)
+	if !options.skipRelease && options.repository != nil && options.token == "" {
+		utils.Fatal(
+			"In order to publish with a related %[1]s release, you must provide a %[1]s API token.", options.repository.Provider.Title(),
+		)
+	}
+
+	if monorepo {
+		publishPackages(options, args[0])
+	} else {
+		publishVersion(options, newVersion, currentVersion, rawChanges)
 	}
 
 	// TODO@PI:
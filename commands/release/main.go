@@ -7,10 +7,10 @@ package release
 
 import (
 	"fmt"
-	"os"
 	"sort"
 
 	"github.com/Masterminds/semver"
+	"github.com/ShogunPanda/impacca/configuration"
 	"github.com/ShogunPanda/impacca/utils"
 	"github.com/ShogunPanda/tempera"
 	"github.com/spf13/cobra"
@@ -18,66 +18,123 @@ import (
 
 // InitCLI initializes the CLI
 func InitCLI() *cobra.Command {
-	cmd := &cobra.Command{Use: "release", Aliases: []string{"r"}, Short: "Manage GitHub releases.", Run: showReleases}
+	cmd := &cobra.Command{Use: "release", Aliases: []string{"r"}, Short: "Manage GitHub, GitLab and Gitea releases.", Run: showReleases}
 	cmd.PersistentFlags().StringP("remote", "r", "origin", "The git remote name.")
-	cmd.PersistentFlags().StringP("token", "t", "", "The GitHub API token.")
+	cmd.PersistentFlags().StringP("token", "t", "", "The GitHub, GitLab or Gitea API token.")
+	cmd.PersistentFlags().BoolP("from-changelog", "l", false, "Use the version section of the CHANGELOG.md file as release body, when present.")
 
 	cmd.AddCommand(&cobra.Command{
-		Use: "show <version>", Aliases: []string{"r"}, Short: "Show GitHub release.",
+		Use: "show <version>", Aliases: []string{"r"}, Short: "Show a release.",
 		Args: cobra.ExactArgs(1), Run: showRelease,
 	})
 
 	cmd.AddCommand(&cobra.Command{
-		Use: "save <version>", Aliases: []string{"s"}, Short: "Updates all changes in version to the GitHub release",
+		Use: "save <version>", Aliases: []string{"s"}, Short: "Updates all changes in version to the release",
 		Args: cobra.MinimumNArgs(1), Run: saveRelease,
 	})
 
 	cmd.AddCommand(&cobra.Command{
-		Use: "regenerate", Aliases: []string{"a"}, Short: "Regenerates all GitHub releases using local versions.",
+		Use: "regenerate", Aliases: []string{"a"}, Short: "Regenerates all releases using local versions.",
 		Run: regenerateReleases,
 	})
 
+	cmd.AddCommand(&cobra.Command{
+		Use: "delete <version>", Aliases: []string{"d"}, Short: "Deletes a release, keeping its GIT tag.",
+		Args: cobra.ExactArgs(1), Run: deleteRelease,
+	})
+
+	assetsCmd := &cobra.Command{
+		Use: "assets <version>", Short: "Shows the assets of a release.",
+		Args: cobra.ExactArgs(1), Run: showAssets,
+	}
+
+	assetsCmd.AddCommand(&cobra.Command{
+		Use: "upload <version>", Aliases: []string{"u"}, Short: "Uploads the configured assets to a release, replacing existing ones.",
+		Args: cobra.ExactArgs(1), Run: uploadAssets,
+	})
+
+	assetsCmd.AddCommand(&cobra.Command{
+		Use: "delete <version> <name...>", Aliases: []string{"d"}, Short: "Deletes assets of a release.",
+		Args: cobra.MinimumNArgs(2), Run: deleteAssets,
+	})
+
+	cmd.AddCommand(assetsCmd)
+
 	return cmd
 }
 
-func printRelease(release utils.Release) {
-	fmt.Printf(tempera.ColorizeTemplate(fmt.Sprintf(
-		"\u0020\u0020\u0020* Version {primary}%s{-} ({secondary}%s{-})\n",
-		release.Version.String(), release.Date.Format("2006-01-02"),
-	)))
-
-	if release.Body != "" {
-		fmt.Println("\n" + utils.Indent(release.Body, "\u0020\u0020\u0020\u0020\u0020"))
+func useChangelog(cmd *cobra.Command) bool {
+	if !cmd.Flags().Changed("from-changelog") {
+		return configuration.Current.Release.FromChangelog
 	}
 
-	fmt.Println("")
+	fromChangelog, _ := cmd.Flags().GetBool("from-changelog")
+	return fromChangelog
 }
 
-func showReleases(cmd *cobra.Command, args []string) {
+func detectRepository(cmd *cobra.Command) (*utils.Repository, string) {
 	remote, _ := cmd.Flags().GetString("remote")
-	repository := utils.DetectGithubRepository(remote, false)
+	token, _ := cmd.Flags().GetString("token")
+	repository := utils.DetectRepository(remote, false)
 
-	res := utils.GitHubReleaseAPICall(
-		"get GitHub releases", "GET", fmt.Sprintf("/repos/%s/releases", repository),
-		"", map[string]string{}, true,
-	)
+	return repository, repository.Token(token)
+}
 
-	var releases []utils.Release
-	err := res.JSON(&releases)
+func parseVersion(rawVersion string) *semver.Version {
+	version, err := utils.ParseVersion(rawVersion)
 
 	if err != nil {
-		utils.Fatal("Cannot decode JSON response to get GitHub releases: {errorPrimary}%s{-}", err.Error())
+		utils.Fatal("Cannot parse {errorPrimary}%s{-} as a version: {errorPrimary}%s{-}", rawVersion, err.Error())
 	}
 
+	return version
+}
+
+func findRelease(cmd *cobra.Command, rawVersion string) (*utils.Repository, string, *utils.Release) {
+	version := parseVersion(rawVersion)
+	repository, token := detectRepository(cmd)
+	release := repository.Provider.Get(repository, token, utils.VersionTag(utils.FormatVersion(version)))
+
+	if release == nil {
+		utils.Fatal("Cannot find %s release {errorPrimary}%s{-}.", repository.Provider.Title(), utils.FormatVersion(version))
+	}
+
+	return repository, token, release
+}
+
+func printRelease(release utils.Release) {
+	name, date := release.Name, "unreleased"
+
+	if release.Version != nil {
+		name = utils.FormatVersion(release.Version)
+	}
+
+	if release.Date != nil {
+		date = release.Date.Format("2006-01-02")
+	}
+
+	fmt.Printf(tempera.ColorizeTemplate(fmt.Sprintf("\u0020\u0020\u0020* Version {primary}%s{-} ({secondary}%s{-})\n", name, date)))
+
+	if release.Body != "" {
+		fmt.Println("\n" + utils.Indent(release.Body, "\u0020\u0020\u0020\u0020\u0020"))
+	}
+
+	fmt.Println("")
+}
+
+func showReleases(cmd *cobra.Command, args []string) {
+	repository, token := detectRepository(cmd)
+	releases := repository.Provider.List(repository, token)
+
 	if len(releases) == 0 {
-		utils.Warn("No GitHub releases found.")
+		utils.Warn("No %s releases found.", repository.Provider.Title())
 		return
 	}
 
 	// Sort release by version, descending
 	sort.SliceStable(releases, func(i, j int) bool { return releases[i].Version.GreaterThan(releases[j].Version) })
 
-	utils.Info("Found {secondary}%d{-} GitHub release(s):\n", len(releases))
+	utils.Info("Found {secondary}%d{-} %s release(s):\n", len(releases), repository.Provider.Title())
 
 	for _, release := range releases {
 		printRelease(release)
@@ -85,56 +142,94 @@ func showReleases(cmd *cobra.Command, args []string) {
 }
 
 func showRelease(cmd *cobra.Command, args []string) {
-	remote, _ := cmd.Flags().GetString("remote")
-	repository := utils.DetectGithubRepository(remote, false)
-	version, _ := semver.NewVersion(args[0])
+	repository, _, release := findRelease(cmd, args[0])
+
+	utils.Info("Found one %s release:\n", repository.Provider.Title())
+	printRelease(*release)
+}
 
-	res := utils.GitHubReleaseAPICall(
-		"get a GitHub release", "GET", fmt.Sprintf("/repos/%s/releases/tags/v%s", repository, version),
-		"", map[string]string{}, true,
-	)
+func saveRelease(cmd *cobra.Command, args []string) {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	version := parseVersion(args[0])
+	repository, token := detectRepository(cmd)
 
-	if res.StatusCode == 404 {
-		utils.Fatal("Cannot find GitHub release {errorPrimary}%s{-}.", version.String())
+	release := utils.SaveRelease(version, repository, token, useChangelog(cmd), dryRun)
+	utils.UploadReleaseAssets(repository, token, release, dryRun)
+}
+
+func regenerateReleases(cmd *cobra.Command, args []string) {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	repository, token := detectRepository(cmd)
+	fromChangelog := useChangelog(cmd)
+	versions := utils.GetVersions()
+
+	for _, version := range versions {
+		utils.SaveRelease(version, repository, token, fromChangelog, dryRun)
 	}
+}
 
-	var release utils.Release
-	err := res.JSON(&release)
+func deleteRelease(cmd *cobra.Command, args []string) {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	repository, token, release := findRelease(cmd, args[0])
 
-	if err != nil {
-		utils.Fatal("Cannot decode JSON response to get a GitHub release: {errorPrimary}%s{-}", err.Error())
+	if utils.NotifyStep(dryRun, "", "Will delete", "Deleting", " %s release {primary}%s{-}...", repository.Provider.Title(), release.Name) {
+		repository.Provider.Delete(repository, token, release)
+	}
+}
+
+func showAssets(cmd *cobra.Command, args []string) {
+	repository, token, release := findRelease(cmd, args[0])
+	assets := repository.Provider.ListAssets(repository, token, release)
+
+	if len(assets) == 0 {
+		utils.Warn("No assets found for %s release {secondary}%s{-}.", repository.Provider.Title(), release.Name)
+		return
+	}
+
+	utils.Info("Found {secondary}%d{-} asset(s) for %s release {secondary}%s{-}:\n", len(assets), repository.Provider.Title(), release.Name)
+
+	for _, asset := range assets {
+		size := ""
+
+		if asset.Size > 0 {
+			size = fmt.Sprintf(" ({secondary}%s{-})", utils.FormatSize(asset.Size))
+		}
+
+		fmt.Printf(tempera.ColorizeTemplate(fmt.Sprintf("\u0020\u0020\u0020* {primary}%s{-}%s: %s\n", asset.Name, size, asset.URL)))
 	}
 
-	utils.Info("Found one GitHub release:\n")
-	printRelease(release)
+	fmt.Println("")
 }
 
-func saveRelease(cmd *cobra.Command, args []string) {
+func uploadAssets(cmd *cobra.Command, args []string) {
 	dryRun, _ := cmd.Flags().GetBool("dry-run")
-	remote, _ := cmd.Flags().GetString("remote")
-	token, _ := cmd.Flags().GetString("token")
-	repository := utils.DetectGithubRepository(remote, false)
-	version, _ := semver.NewVersion(args[0])
+	repository, token, release := findRelease(cmd, args[0])
 
-	if token == "" {
-		token = os.Getenv("IMPACCA_GITHUB_TOKEN")
+	if len(configuration.Current.Release.Assets) == 0 {
+		utils.Fatal("No assets to upload. Configure them using {errorPrimary}release.assets{-} in the {errorPrimary}.impacca.json{-} file.")
 	}
 
-	utils.SaveRelease(version, repository, remote, token, dryRun)
+	utils.UploadReleaseAssets(repository, token, release, dryRun)
 }
 
-func regenerateReleases(cmd *cobra.Command, args []string) {
+func deleteAssets(cmd *cobra.Command, args []string) {
 	dryRun, _ := cmd.Flags().GetBool("dry-run")
-	remote, _ := cmd.Flags().GetString("remote")
-	token, _ := cmd.Flags().GetString("token")
-	repository := utils.DetectGithubRepository(remote, false)
-	versions := utils.GetVersions()
+	repository, token, release := findRelease(cmd, args[0])
+	existing := make(map[string]utils.ReleaseAsset)
 
-	if token == "" {
-		token = os.Getenv("IMPACCA_GITHUB_TOKEN")
+	for _, asset := range repository.Provider.ListAssets(repository, token, release) {
+		existing[asset.Name] = asset
 	}
 
-	for _, version := range versions {
-		utils.SaveRelease(version, repository, remote, token, dryRun)
+	for _, name := range args[1:] {
+		asset, found := existing[name]
+
+		if !found {
+			utils.Fatal("Cannot find asset {errorPrimary}%s{-} in %s release {errorPrimary}%s{-}.", name, repository.Provider.Title(), release.Name)
+		}
+
+		if utils.NotifyStep(dryRun, "", "Will delete", "Deleting", " asset {primary}%s{-}...", name) {
+			repository.Provider.DeleteAsset(repository, token, release, asset)
+		}
 	}
 }
@@ -19,14 +19,39 @@ func InitCLI() *cobra.Command {
 		Use: "version [version]", Aliases: []string{"v"}, Short: "Show or set the current version.", Args: cobra.MaximumNArgs(1), Run: manageVersion,
 	}
 
+	cmd.Flags().StringP("preid", "i", "", "The identifier of prerelease versions, like beta.")
+
 	cmd.AddCommand(&cobra.Command{Use: "list", Aliases: []string{"a", "all", "l"}, Short: "Show all versions.", Run: listVersion})
 	cmd.AddCommand(&cobra.Command{Use: "raw", Aliases: []string{"r"}, Short: "Only show the raw version number.", Run: showRawVersion})
+	cmd.AddCommand(&cobra.Command{
+		Use: "next", Aliases: []string{"n"}, Short: "Show the next version computed from changes since the current version.", Run: showNextVersion,
+	})
 
 	return cmd
 }
 
 func showRawVersion(cmd *cobra.Command, args []string) {
-	fmt.Println(utils.GetCurrentVersion())
+	fmt.Println(utils.FormatVersion(utils.GetCurrentVersion()))
+}
+
+func showNextVersion(cmd *cobra.Command, args []string) {
+	currentVersion := utils.GetCurrentVersion()
+	changes := utils.ListChanges(utils.FormatVersion(currentVersion), "")
+	newVersion, bump, reasons := utils.DetectNextVersion(currentVersion, changes)
+
+	if newVersion == nil {
+		utils.Info("Current version is {primary}%s{-} and no changes require a release.", utils.FormatVersion(currentVersion))
+		return
+	}
+
+	utils.Info(
+		"Next version is {primary}%s{-} ({secondary}%s{-} release), justified by {secondary}%d{-} change(s):",
+		utils.FormatVersion(newVersion), bump, len(reasons),
+	)
+
+	for _, reason := range reasons {
+		fmt.Printf(tempera.ColorizeTemplate("\u0020\u0020\u0020* {primary}%s{-} ({secondary}%s{-})\n"), reason.Change.Summary(), reason.Change.Hash)
+	}
 }
 
 func listVersion(cmd *cobra.Command, args []string) {
@@ -35,15 +60,20 @@ func listVersion(cmd *cobra.Command, args []string) {
 	utils.Info("Found {secondary}%d{-} versions(s):", len(versions))
 
 	for _, version := range versions {
-		fmt.Printf(tempera.ColorizeTemplate("\u0020\u0020\u0020* {primary}%s{-}\n"), version)
+		fmt.Printf(tempera.ColorizeTemplate("\u0020\u0020\u0020* {primary}%s{-}\n"), utils.FormatVersion(version))
 	}
 }
 
 func manageVersion(cmd *cobra.Command, args []string) {
 	currentVersion := utils.GetCurrentVersion()
 	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	preid, _ := cmd.Flags().GetString("preid")
+
+	utils.Info("Current version is: {primary}%s{-}", utils.FormatVersion(currentVersion))
 
-	utils.Info("Current version is: {primary}%s{-}", currentVersion)
+	if declared := utils.DetectPackageManager().CurrentVersion(); declared != "" && declared != utils.FormatVersion(currentVersion) {
+		utils.Info("The package files declare the version {secondary}%s{-}.", declared)
+	}
 
 	if len(args) == 0 {
 		return
@@ -53,7 +83,7 @@ func manageVersion(cmd *cobra.Command, args []string) {
 		utils.GitMustBeClean("change the version")
 	}
 
-	newVersion := utils.ChangeVersion(currentVersion, args[0])
+	newVersion := utils.ChangeVersion(currentVersion, args[0], preid)
 
 	utils.UpdateVersion(newVersion, currentVersion, dryRun)
 	utils.Complete()
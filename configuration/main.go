@@ -17,15 +17,167 @@ import (
 type commitMessages struct {
 	Versioning string `json:"versioning"`
 	Changelog  string `json:"changelog"`
+	Snapshot   string `json:"snapshot"`
+}
+
+// BumpRule maps a commit type, scope or footer to a version change
+type BumpRule struct {
+	Type   string `json:"type"`
+	Scope  string `json:"scope"`
+	Footer string `json:"footer"`
+	Bump   string `json:"bump"`
+}
+
+// VersioningSettings selects how versions are formatted and incremented
+type VersioningSettings struct {
+	Scheme  string `json:"scheme"`
+	Pattern string `json:"pattern"`
+}
+
+// VersionFile describes how to update the version in files matching a glob.
+// Exactly one of Regex, JSON, YAML and TOML must be set. Count is the expected number of replacements in each file.
+type VersionFile struct {
+	Glob  string `json:"glob"`
+	Regex string `json:"regex"`
+	JSON  string `json:"json"`
+	YAML  string `json:"yaml"`
+	TOML  string `json:"toml"`
+	Count int    `json:"count"`
+}
+
+type versionBumping struct {
+	Rules             []BumpRule `json:"rules"`
+	Default           string     `json:"default"`
+	Breaking          string     `json:"breaking"`
+	PreMajor          bool       `json:"preMajor"`
+	IgnorePrereleases bool       `json:"ignorePrereleases"`
+}
+
+// ChangelogSection groups changes of some commit types under a heading.
+// The special type "!" matches breaking changes while "*" matches all types not matched by other sections.
+type ChangelogSection struct {
+	Title string   `json:"title"`
+	Types []string `json:"types"`
+}
+
+type changelog struct {
+	Format                   string             `json:"format"`
+	Grouped                  bool               `json:"grouped"`
+	Sections                 []ChangelogSection `json:"sections"`
+	HiddenTypes              []string           `json:"hiddenTypes"`
+	KeepAChangelogCategories map[string]string  `json:"keepAChangelogCategories"`
+}
+
+// TemplateSettings selects a template by preset name, inline source or file path
+type TemplateSettings struct {
+	Preset string `json:"preset"`
+	Inline string `json:"inline"`
+	File   string `json:"file"`
+}
+
+type templates struct {
+	Changelog TemplateSettings `json:"changelog"`
+	Release   TemplateSettings `json:"release"`
+}
+
+type release struct {
+	FromChangelog bool     `json:"fromChangelog"`
+	Provider      string   `json:"provider"`
+	Assets        []string `json:"assets"`
+	Checksums     bool     `json:"checksums"`
+}
+
+type github struct {
+	Hosts  []string `json:"hosts"`
+	APIURL string   `json:"apiURL"`
+}
+
+// ReleaseLink is a link attached to GitLab releases. The {{version}} and {{tag}} placeholders of the URL are replaced.
+type ReleaseLink struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	Type string `json:"type"`
+}
+
+type gitlab struct {
+	Hosts []string      `json:"hosts"`
+	Links []ReleaseLink `json:"links"`
+}
+
+type gitea struct {
+	Hosts []string `json:"hosts"`
+}
+
+type golang struct {
+	EnforceModulePath bool `json:"enforceModulePath"`
+	RewriteModulePath bool `json:"rewriteModulePath"`
+	Tidy              bool `json:"tidy"`
+	Vet               bool `json:"vet"`
+}
+
+type python struct {
+	Repository string `json:"repository"`
+}
+
+type helm struct {
+	AppVersion bool   `json:"appVersion"`
+	Repository string `json:"repository"`
+}
+
+type container struct {
+	Image      string `json:"image"`
+	Dockerfile string `json:"dockerfile"`
+	Builder    string `json:"builder"`
+	Push       bool   `json:"push"`
+}
+
+type monorepo struct {
+	Enabled  bool     `json:"enabled"`
+	Packages []string `json:"packages"`
+	TagStyle string   `json:"tagStyle"`
 }
 
 // Configuration represents the Impacca configuration
 type Configuration struct {
-	CommitMessages commitMessages `json:"commitMessages"`
+	TagTemplate    string             `json:"tagTemplate"`
+	Versioning     VersioningSettings `json:"versioning"`
+	VersionFiles   []VersionFile      `json:"versionFiles"`
+	CommitMessages commitMessages     `json:"commitMessages"`
+	Bumping        versionBumping     `json:"bumping"`
+	Changelog      changelog          `json:"changelog"`
+	Templates      templates          `json:"templates"`
+	Release        release            `json:"release"`
+	GitHub         github             `json:"github"`
+	GitLab         gitlab             `json:"gitlab"`
+	Gitea          gitea              `json:"gitea"`
+	Monorepo       monorepo           `json:"monorepo"`
+	Go             golang             `json:"go"`
+	Python         python             `json:"python"`
+	Helm           helm               `json:"helm"`
+	Container      container          `json:"container"`
+}
+
+// fillDefaultCollections sets the slices and maps not present in the configuration file to their defaults.
+// They are decoded starting from nil as otherwise the user entries would be merged with the default ones.
+func (c *Configuration) fillDefaultCollections() {
+	if c.Bumping.Rules == nil {
+		c.Bumping.Rules = defaultConfiguration.Bumping.Rules
+	}
+
+	if c.Changelog.Sections == nil {
+		c.Changelog.Sections = defaultConfiguration.Changelog.Sections
+	}
+
+	if c.Changelog.KeepAChangelogCategories == nil {
+		c.Changelog.KeepAChangelogCategories = defaultConfiguration.Changelog.KeepAChangelogCategories
+	}
 }
 
 func loadConfiguration() Configuration {
 	var configuration = defaultConfiguration
+	configuration.Bumping.Rules = nil
+	configuration.Changelog.Sections = nil
+	configuration.Changelog.KeepAChangelogCategories = nil
 
 	// First of all, try to load the file starting from the current folder and traversing up to root - Then trying with the home folder
 	visitedFolders := make(map[string]bool)
@@ -72,14 +224,74 @@ func loadConfiguration() Configuration {
 
 		if err != nil {
 			console.Warn("The configuration file {yellow|bold}%s{-} is not a valid JSON file. Ignoring it.", configurationPath)
+			configuration = defaultConfiguration
 		}
 	}
 
+	configuration.fillDefaultCollections()
 	return configuration
 }
 
 var defaultConfiguration = Configuration{
-	CommitMessages: commitMessages{Versioning: "Version %s.", Changelog: "Updated CHANGELOG.md."},
+	TagTemplate:    "v{{version}}",
+	Versioning:     VersioningSettings{Scheme: "semver", Pattern: "YYYY.MM.MICRO"},
+	VersionFiles:   []VersionFile{},
+	CommitMessages: commitMessages{Versioning: "Version %s.", Changelog: "Updated CHANGELOG.md.", Snapshot: "Prepare for version %s."},
+	Bumping: versionBumping{
+		Rules: []BumpRule{
+			{Type: "feat", Bump: "minor"},
+			{Type: "fix", Bump: "patch"},
+			{Type: "perf", Bump: "patch"},
+			{Type: "revert", Bump: "patch"},
+			{Type: "refactor", Bump: "patch"},
+			{Type: "build", Bump: "none"},
+			{Type: "chore", Bump: "none"},
+			{Type: "ci", Bump: "none"},
+			{Type: "docs", Bump: "none"},
+			{Type: "style", Bump: "none"},
+			{Type: "test", Bump: "none"},
+		},
+		Default:  "patch",
+		Breaking: "major",
+		PreMajor: false,
+	},
+	Changelog: changelog{
+		Format:  "impacca",
+		Grouped: false,
+		Sections: []ChangelogSection{
+			{Title: "Breaking Changes", Types: []string{"!"}},
+			{Title: "Features", Types: []string{"feat"}},
+			{Title: "Bug Fixes", Types: []string{"fix"}},
+			{Title: "Performance", Types: []string{"perf"}},
+			{Title: "Other Changes", Types: []string{"*"}},
+		},
+		HiddenTypes: []string{},
+		KeepAChangelogCategories: map[string]string{
+			"feat":       "Added",
+			"fix":        "Fixed",
+			"perf":       "Changed",
+			"refactor":   "Changed",
+			"revert":     "Changed",
+			"deprecate":  "Deprecated",
+			"deprecated": "Deprecated",
+			"remove":     "Removed",
+			"removed":    "Removed",
+			"security":   "Security",
+		},
+	},
+	Templates: templates{
+		Changelog: TemplateSettings{Preset: "impacca"},
+		Release:   TemplateSettings{Preset: "impacca"},
+	},
+	Release:   release{FromChangelog: false, Provider: "", Assets: []string{}, Checksums: true},
+	GitHub:    github{Hosts: []string{}, APIURL: ""},
+	GitLab:    gitlab{Hosts: []string{}, Links: []ReleaseLink{}},
+	Gitea:     gitea{Hosts: []string{}},
+	Monorepo:  monorepo{Enabled: false, Packages: []string{}, TagStyle: ""},
+	Go:        golang{EnforceModulePath: true, RewriteModulePath: false, Tidy: false, Vet: false},
+	Python:    python{Repository: ""},
+	Helm:      helm{AppVersion: false, Repository: ""},
+	Container: container{Image: "", Dockerfile: "Dockerfile", Builder: "docker", Push: true},
 }
 
 // Current is the current Impacca configuration
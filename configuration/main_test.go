@@ -0,0 +1,83 @@
+/*
+ * This file is part of impacca. Copyright (C) 2013 and above Shogun <shogun@cowtech.it>.
+ * Licensed under the MIT license, which can be found at https://choosealicense.com/licenses/mit.
+ */
+
+package configuration
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func loadTestConfiguration(t *testing.T, contents string) Configuration {
+	t.Helper()
+
+	folder, err := ioutil.TempDir("", "impacca-configuration")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(folder)
+
+	if err := ioutil.WriteFile(filepath.Join(folder, ".impacca.json"), []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cwd, _ := os.Getwd()
+	home := os.Getenv("HOME")
+
+	defer os.Chdir(cwd)
+	defer os.Setenv("HOME", home)
+
+	os.Chdir(folder)
+	os.Setenv("HOME", folder)
+
+	return loadConfiguration()
+}
+
+func TestLoadConfigurationReplacesCollections(t *testing.T) {
+	configuration := loadTestConfiguration(t, `{
+		"bumping": {"rules": [{"footer": "Security", "bump": "patch"}]},
+		"changelog": {"sections": [{"title": "Changes", "types": ["*"]}], "keepAChangelogCategories": {"feat": "Added"}}
+	}`)
+
+	if expected := []BumpRule{{Footer: "Security", Bump: "patch"}}; !reflect.DeepEqual(configuration.Bumping.Rules, expected) {
+		t.Errorf("unexpected rules: %+v", configuration.Bumping.Rules)
+	}
+
+	if expected := []ChangelogSection{{Title: "Changes", Types: []string{"*"}}}; !reflect.DeepEqual(configuration.Changelog.Sections, expected) {
+		t.Errorf("unexpected sections: %+v", configuration.Changelog.Sections)
+	}
+
+	if expected := map[string]string{"feat": "Added"}; !reflect.DeepEqual(configuration.Changelog.KeepAChangelogCategories, expected) {
+		t.Errorf("unexpected categories: %+v", configuration.Changelog.KeepAChangelogCategories)
+	}
+
+	if defaultConfiguration.Bumping.Rules[0].Type != "feat" || defaultConfiguration.Changelog.KeepAChangelogCategories["fix"] != "Fixed" {
+		t.Error("the default configuration has been modified")
+	}
+}
+
+func TestLoadConfigurationKeepsDefaults(t *testing.T) {
+	configuration := loadTestConfiguration(t, `{"bumping": {"default": "minor"}, "release": {"assets": ["dist/*"]}}`)
+
+	if configuration.Bumping.Default != "minor" || configuration.Bumping.Breaking != "major" {
+		t.Errorf("unexpected bumping: %+v", configuration.Bumping)
+	}
+
+	if !reflect.DeepEqual(configuration.Bumping.Rules, defaultConfiguration.Bumping.Rules) {
+		t.Errorf("unexpected rules: %+v", configuration.Bumping.Rules)
+	}
+
+	if !reflect.DeepEqual(configuration.Changelog.Sections, defaultConfiguration.Changelog.Sections) {
+		t.Errorf("unexpected sections: %+v", configuration.Changelog.Sections)
+	}
+
+	if !configuration.Release.Checksums || !reflect.DeepEqual(configuration.Release.Assets, []string{"dist/*"}) {
+		t.Errorf("unexpected release: %+v", configuration.Release)
+	}
+}
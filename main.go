@@ -6,28 +6,61 @@
 package main
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/ShogunPanda/tempera"
 	"github.com/spf13/cobra"
 
 	"github.com/ShogunPanda/impacca/commands/changelog"
+	"github.com/ShogunPanda/impacca/commands/packages"
 	"github.com/ShogunPanda/impacca/commands/publish"
 	"github.com/ShogunPanda/impacca/commands/release"
 	"github.com/ShogunPanda/impacca/commands/version"
+	"github.com/ShogunPanda/impacca/utils"
 )
 
+func prepare(cmd *cobra.Command, args []string) {
+	utils.ForcedPackageManager, _ = cmd.Flags().GetString("package-manager")
+
+	// Validate the package manager as soon as possible
+	if utils.ForcedPackageManager != "" {
+		utils.DetectPackageManager()
+	}
+
+	name, _ := cmd.Flags().GetString("package")
+
+	if name == "" {
+		return
+	}
+
+	pkg := utils.FindPackage(utils.DiscoverPackages(), name)
+
+	if pkg == nil {
+		utils.Fatal("Cannot find the package {errorPrimary}%s{-} in the repository.", name)
+	}
+
+	utils.SelectPackage(pkg)
+}
+
 func main() {
 	tempera.AddCustomStyle("primary", "bold", "blue")
 	tempera.AddCustomStyle("secondary", "bold", "yellow")
 	tempera.AddCustomStyle("errorPrimary", "bold", "white")
 
-	var rootCmd = &cobra.Command{Use: "impacca", Short: "Package releasing made easy."}
+	var rootCmd = &cobra.Command{Use: "impacca", Short: "Package releasing made easy.", PersistentPreRun: prepare}
 	rootCmd.Version = "2.0.5"
 	rootCmd.PersistentFlags().BoolP("dry-run", "n", false, "Do not execute write operation, only show them.")
+	rootCmd.PersistentFlags().StringP("package", "P", "", "The monorepo package to operate on.")
+	rootCmd.PersistentFlags().StringP(
+		"package-manager", "M", "", fmt.Sprintf("The package manager to use instead of detecting it. Can be: %s.", strings.Join(utils.PackageManagerNames(), ", ")),
+	)
 
 	rootCmd.AddCommand(version.InitCLI())
 	rootCmd.AddCommand(changelog.InitCLI())
 	rootCmd.AddCommand(publish.InitCLI())
 	rootCmd.AddCommand(release.InitCLI())
+	rootCmd.AddCommand(packages.InitCLI())
 
 	rootCmd.Execute()
 }
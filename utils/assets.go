@@ -0,0 +1,224 @@
+/*
+ * This file is part of impacca. Copyright (C) 2013 and above Shogun <shogun@cowtech.it>.
+ * Licensed under the MIT license, which can be found at https://choosealicense.com/licenses/mit.
+ */
+
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/ShogunPanda/impacca/configuration"
+	"gopkg.in/h2non/gentleman.v2"
+	"gopkg.in/h2non/gentleman.v2/context"
+	"gopkg.in/h2non/gentleman.v2/plugins/body"
+)
+
+// ReleaseAsset represents a file attached to a release
+type ReleaseAsset struct {
+	// ID is the identifier of the asset on the forge.
+	ID   int
+	Name string
+	// Size is the size in bytes, or 0 if the forge does not provide it.
+	Size int64
+	URL  string
+}
+
+// FormatSize formats a size in bytes, like 1.5 MB.
+func FormatSize(size int64) string {
+	if size < 1024 {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	value := float64(size)
+	unit := -1
+
+	for value >= 1024 && unit < 3 {
+		value /= 1024
+		unit++
+	}
+
+	return fmt.Sprintf("%.1f %s", value, []string{"KB", "MB", "GB", "TB"}[unit])
+}
+
+// temporaryAssetName returns the name used to upload a file replacing an asset, on forges requiring unique names.
+func temporaryAssetName(name string) string {
+	return name + ".uploading"
+}
+
+// openAssetFile opens a file to upload and returns its size.
+func openAssetFile(file string) (*os.File, int64) {
+	source, err := os.Open(file)
+
+	if err != nil {
+		Fatal("Cannot read file {errorPrimary}%s{-}: {errorPrimary}%s{-}", file, err.Error())
+	}
+
+	info, err := source.Stat()
+
+	if err != nil {
+		Fatal("Cannot read file {errorPrimary}%s{-}: {errorPrimary}%s{-}", file, err.Error())
+	}
+
+	return source, info.Size()
+}
+
+// useStreamBody streams a reader as the body of a request.
+// The length is sent as forges reject uploads using chunked transfer encoding.
+func useStreamBody(req *gentleman.Request, reader io.Reader, length int64) {
+	req.Use(body.Reader(reader))
+	req.UseRequest(func(ctx *context.Context, h context.Handler) {
+		ctx.Request.ContentLength = length
+		h.Next(ctx)
+	})
+}
+
+// ReleaseAssetFiles returns the files matching the configured assets globs, relative to the current folder.
+func ReleaseAssetFiles() []string {
+	cwd, _ := os.Getwd()
+	files := make([]string, 0)
+	names := make(map[string]string)
+
+	for _, glob := range configuration.Current.Release.Assets {
+		matches, err := filepath.Glob(filepath.Join(cwd, glob))
+
+		if err != nil {
+			Fatal("Invalid assets glob {errorPrimary}%s{-}: {errorPrimary}%s{-}", glob, err.Error())
+		} else if len(matches) == 0 {
+			Warn("The assets glob {secondary}%s{-} does not match any file.", glob)
+		}
+
+		for _, match := range matches {
+			if info, err := os.Stat(match); err != nil || info.IsDir() {
+				continue
+			}
+
+			// Assets are identified by name, so two files with the same name would replace each other
+			name := filepath.Base(match)
+
+			if previous, found := names[name]; found {
+				if previous != match {
+					Fatal("The assets {errorPrimary}%s{-} and {errorPrimary}%s{-} have the same name.", previous, match)
+				}
+
+				continue
+			}
+
+			names[name] = match
+			files = append(files, match)
+		}
+	}
+
+	return files
+}
+
+// writeChecksumFile writes the SHA-256 checksum of a file in a folder, in the format used by sha256sum.
+func writeChecksumFile(file, folder string) string {
+	source, err := os.Open(file)
+
+	if err != nil {
+		Fatal("Cannot read file {errorPrimary}%s{-}: {errorPrimary}%s{-}", file, err.Error())
+	}
+
+	defer source.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, source); err != nil {
+		Fatal("Cannot read file {errorPrimary}%s{-}: {errorPrimary}%s{-}", file, err.Error())
+	}
+
+	name := filepath.Base(file)
+	checksumFile := filepath.Join(folder, name+".sha256")
+	contents := fmt.Sprintf("%s  %s\n", hex.EncodeToString(hash.Sum(nil)), name)
+
+	if err := ioutil.WriteFile(checksumFile, []byte(contents), 0644); err != nil {
+		Fatal("Cannot write file {errorPrimary}%s{-}: {errorPrimary}%s{-}", checksumFile, err.Error())
+	}
+
+	return checksumFile
+}
+
+// UploadReleaseAssets uploads the configured assets, and their checksums if enabled, to a release.
+// Existing assets with the same name are replaced. In dry-run mode the release can be nil.
+func UploadReleaseAssets(repository *Repository, token string, release *Release, dryRun bool) {
+	files := ReleaseAssetFiles()
+
+	if len(files) == 0 {
+		return
+	}
+
+	if configuration.Current.Release.Checksums {
+		folder, err := ioutil.TempDir("", "impacca-checksums")
+
+		if err != nil {
+			Fatal("Cannot create a temporary folder: {errorPrimary}%s{-}", err.Error())
+		}
+
+		defer os.RemoveAll(folder)
+
+		checksums := make([]string, 0, len(files))
+
+		for _, file := range files {
+			checksums = append(checksums, writeChecksumFile(file, folder))
+		}
+
+		files = append(files, checksums...)
+	}
+
+	existing := make(map[string]ReleaseAsset)
+
+	if release != nil {
+		for _, asset := range repository.Provider.ListAssets(repository, token, release) {
+			existing[asset.Name] = asset
+		}
+	}
+
+	// Remove files left by replacements which failed in previous executions
+	for _, file := range files {
+		if leftover, found := existing[temporaryAssetName(filepath.Base(file))]; found {
+			if NotifyStep(dryRun, "", "Will delete", "Deleting", " the incomplete asset {primary}%s{-} ...", leftover.Name) {
+				repository.Provider.DeleteAsset(repository, token, release, leftover)
+			}
+		}
+	}
+
+	var total int64
+
+	for i, file := range files {
+		name := filepath.Base(file)
+		info, err := os.Stat(file)
+
+		if err != nil {
+			Fatal("Cannot read file {errorPrimary}%s{-}: {errorPrimary}%s{-}", file, err.Error())
+		}
+
+		total += info.Size()
+		asset, found := existing[name]
+		showOnlyVerb, realVerb := "Will upload", "Uploading"
+
+		if found {
+			showOnlyVerb, realVerb = "Will replace", "Replacing"
+		}
+
+		if NotifyStep(
+			dryRun, "", showOnlyVerb, realVerb,
+			" asset {secondary}%d/%d{-} {primary}%s{-} ({secondary}%s{-}) ...", i+1, len(files), name, FormatSize(info.Size()),
+		) {
+			if found {
+				repository.Provider.ReplaceAsset(repository, token, release, asset, file)
+			} else {
+				repository.Provider.UploadAsset(repository, token, release, file)
+			}
+		}
+	}
+
+	if !dryRun {
+		Info("Uploaded {secondary}%d{-} asset(s), {secondary}%s{-} in total.", len(files), FormatSize(total))
+	}
+}
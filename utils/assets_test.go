@@ -0,0 +1,103 @@
+/*
+ * This file is part of impacca. Copyright (C) 2013 and above Shogun <shogun@cowtech.it>.
+ * Licensed under the MIT license, which can be found at https://choosealicense.com/licenses/mit.
+ */
+
+package utils
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ShogunPanda/impacca/configuration"
+)
+
+func TestFormatSize(t *testing.T) {
+	cases := map[int64]string{0: "0 B", 1023: "1023 B", 1024: "1.0 KB", 1536: "1.5 KB", 5 * 1024 * 1024: "5.0 MB", 3 << 40: "3.0 TB"}
+
+	for size, expected := range cases {
+		if actual := FormatSize(size); actual != expected {
+			t.Errorf("%d: expected %s, got %s", size, expected, actual)
+		}
+	}
+}
+
+func TestReleaseAssetFiles(t *testing.T) {
+	defer useTestFolder(t, map[string]string{"dist/app.bin": "", "dist/app.txt": "", "dist/docs/index.html": "", "LICENSE": ""})()
+
+	previous := configuration.Current.Release
+	configuration.Current.Release.Assets = []string{"dist/*", "dist/app.bin", "LICENSE"}
+	defer func() { configuration.Current.Release = previous }()
+
+	cwd, _ := os.Getwd()
+	names := make([]string, 0)
+
+	for _, file := range ReleaseAssetFiles() {
+		relative, _ := filepath.Rel(cwd, file)
+		names = append(names, relative)
+	}
+
+	if expected := []string{"dist/app.bin", "dist/app.txt", "LICENSE"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected assets %v, got %v", expected, names)
+	}
+}
+
+func TestUploadReleaseAssets(t *testing.T) {
+	defer useTestFolder(t, map[string]string{"dist/app.bin": "binary"})()
+
+	requests := make([]string, 0)
+	uploads := make(map[string]string)
+
+	repository, done := useGiteaTestRepository(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, strings.TrimSpace(r.Method+" "+r.URL.Path+" "+r.URL.Query().Get("name")))
+
+		switch r.Method {
+		case "GET":
+			w.Write([]byte(`[{"id": 1, "name": "app.bin", "size": 3}]`))
+		case "POST":
+			file, _, err := r.FormFile("attachment")
+			if err != nil {
+				t.Fatalf("missing attachment: %s", err.Error())
+			}
+
+			contents, _ := ioutil.ReadAll(file)
+			uploads[r.URL.Query().Get("name")] = string(contents)
+
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id": 3, "name": "` + r.URL.Query().Get("name") + `"}`))
+		case "DELETE":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.Write([]byte(`{"id": 3, "name": "app.bin"}`))
+		}
+	})
+
+	defer done()
+
+	configuration.Current.Release.Assets = []string{"dist/*"}
+	configuration.Current.Release.Checksums = true
+
+	UploadReleaseAssets(repository, "token", &Release{ID: 10}, false)
+
+	expectedRequests := []string{
+		"GET /api/v1/repos/owner/repo/releases/10/assets",
+		"POST /api/v1/repos/owner/repo/releases/10/assets app.bin.uploading",
+		"DELETE /api/v1/repos/owner/repo/releases/10/assets/1",
+		"PATCH /api/v1/repos/owner/repo/releases/10/assets/3",
+		"POST /api/v1/repos/owner/repo/releases/10/assets app.bin.sha256",
+	}
+
+	expectedUploads := map[string]string{
+		"app.bin.uploading": "binary",
+		"app.bin.sha256":    "9a3a45d01531a20e89ac6ae10b0b0beb0492acd7216a368aa062d1a5fecaf9cd  app.bin\n",
+	}
+
+	if !reflect.DeepEqual(requests, expectedRequests) || !reflect.DeepEqual(uploads, expectedUploads) {
+		t.Errorf("unexpected requests %v with uploads %v", requests, uploads)
+	}
+}
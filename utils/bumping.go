@@ -0,0 +1,145 @@
+/*
+ * This file is part of impacca. Copyright (C) 2013 and above Shogun <shogun@cowtech.it>.
+ * Licensed under the MIT license, which can be found at https://choosealicense.com/licenses/mit.
+ */
+
+package utils
+
+import (
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/ShogunPanda/impacca/configuration"
+)
+
+const (
+	// NoBump means the change does not require a release
+	NoBump = "none"
+	// PatchBump means the change requires a patch release
+	PatchBump = "patch"
+	// MinorBump means the change requires a minor release
+	MinorBump = "minor"
+	// MajorBump means the change requires a major release
+	MajorBump = "major"
+)
+
+var bumpsPriorities = map[string]int{NoBump: 0, PatchBump: 1, MinorBump: 2, MajorBump: 3}
+
+// ChangeBump represents the version change required by a change
+type ChangeBump struct {
+	Change Change
+	Bump   string
+}
+
+func validateBump(bump, source string) string {
+	bump = strings.ToLower(strings.TrimSpace(bump))
+
+	if _, valid := bumpsPriorities[bump]; !valid {
+		Fatal("Invalid version change {errorPrimary}%s{-} in the {errorPrimary}%s{-} configuration.", bump, source)
+	}
+
+	return bump
+}
+
+func matchBumpRule(rule configuration.BumpRule, change Change) bool {
+	if rule.Type == "" && rule.Scope == "" && rule.Footer == "" {
+		return false
+	}
+
+	if rule.Type != "" && !strings.EqualFold(rule.Type, change.Type) {
+		return false
+	}
+
+	if rule.Scope != "" && !strings.EqualFold(rule.Scope, change.Scope) {
+		return false
+	}
+
+	if rule.Footer != "" {
+		for _, footer := range change.Footers {
+			if strings.EqualFold(rule.Footer, footer.Token) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return true
+}
+
+// DetectChangeBump detects the version change required by a single change.
+func DetectChangeBump(change Change, currentVersion *semver.Version) string {
+	bumping := configuration.Current.Bumping
+
+	if filterCommit(change) {
+		return NoBump
+	}
+
+	if change.Breaking {
+		bump := validateBump(bumping.Breaking, "bumping.breaking")
+
+		// Before 1.0.0, breaking changes can optionally only bump the minor version
+		if bump == MajorBump && bumping.PreMajor && currentVersion.Major() == 0 {
+			bump = MinorBump
+		}
+
+		return bump
+	}
+
+	for _, rule := range bumping.Rules {
+		if matchBumpRule(rule, change) {
+			return validateBump(rule.Bump, "bumping.rules")
+		}
+	}
+
+	return validateBump(bumping.Default, "bumping.default")
+}
+
+// DetectBump detects the version change required by a list of changes.
+// It also returns the changes which justify it.
+func DetectBump(changes []Change, currentVersion *semver.Version) (string, []ChangeBump) {
+	bump := NoBump
+	reasons := make([]ChangeBump, 0)
+
+	for _, change := range changes {
+		changeBump := DetectChangeBump(change, currentVersion)
+
+		if bumpsPriorities[changeBump] > bumpsPriorities[bump] {
+			bump = changeBump
+			reasons = reasons[:0]
+		}
+
+		if changeBump != NoBump && changeBump == bump {
+			reasons = append(reasons, ChangeBump{Change: change, Bump: changeBump})
+		}
+	}
+
+	return bump, reasons
+}
+
+// PrereleaseChange returns the version change publishing a prerelease for a bump.
+// When the current version is a prerelease which already includes the bump, like 1.3.0-beta.0 for a minor bump, only its counter is increased.
+func PrereleaseChange(currentVersion *semver.Version, bump string) string {
+	if currentVersion.Prerelease() != "" {
+		switch {
+		case bump == PatchBump,
+			bump == MinorBump && currentVersion.Patch() == 0,
+			bump == MajorBump && currentVersion.Minor() == 0 && currentVersion.Patch() == 0:
+			return "prerelease"
+		}
+	}
+
+	return "pre" + bump
+}
+
+// DetectNextVersion detects the next version using a list of changes since the current version.
+// If no release is needed, it returns a nil version.
+func DetectNextVersion(currentVersion *semver.Version, changes []Change) (*semver.Version, string, []ChangeBump) {
+	bump, reasons := DetectBump(changes, currentVersion)
+
+	if bump == NoBump {
+		return nil, bump, reasons
+	}
+
+	return ChangeVersion(currentVersion, bump, ""), bump, reasons
+}
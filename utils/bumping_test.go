@@ -0,0 +1,94 @@
+/*
+ * This file is part of impacca. Copyright (C) 2013 and above Shogun <shogun@cowtech.it>.
+ * Licensed under the MIT license, which can be found at https://choosealicense.com/licenses/mit.
+ */
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/Masterminds/semver"
+	"github.com/ShogunPanda/impacca/configuration"
+)
+
+func TestDetectBump(t *testing.T) {
+	previous := configuration.Current.Bumping
+	defer func() { configuration.Current.Bumping = previous }()
+
+	configuration.Current.Bumping.Rules = append(
+		[]configuration.BumpRule{{Footer: "Security", Bump: PatchBump}, {Type: "docs", Scope: "api", Bump: MinorBump}}, previous.Rules...,
+	)
+
+	cases := []struct {
+		version  string
+		preMajor bool
+		messages []string
+		expected string
+		reasons  int
+	}{
+		{"1.2.3", false, []string{}, NoBump, 0},
+		{"1.2.3", false, []string{"chore: Update dependencies", "docs: Fix typo"}, NoBump, 0},
+		{"1.2.3", false, []string{"chore: Update dependencies", "Fixed typo."}, PatchBump, 1},
+		{"1.2.3", false, []string{"fix: Crash", "feat: Arrays", "feat(lexer): Objects"}, MinorBump, 2},
+		{"1.2.3", false, []string{"feat: Arrays", "fix!: Crash"}, MajorBump, 1},
+		{"1.2.3", false, []string{"feat: Arrays", "chore: Drop Node 8\n\nBREAKING CHANGE: Node 10 is required"}, MajorBump, 1},
+		{"0.2.3", true, []string{"feat!: New API"}, MinorBump, 1},
+		{"1.2.3", true, []string{"feat!: New API"}, MajorBump, 1},
+		{"1.2.3", false, []string{"chore: Update dependencies\n\nSecurity: CVE-2024-1234"}, PatchBump, 1},
+		{"1.2.3", false, []string{"docs(api): New endpoint", "docs: Fix typo"}, MinorBump, 1},
+	}
+
+	for _, tc := range cases {
+		configuration.Current.Bumping.PreMajor = tc.preMajor
+		changes := make([]Change, 0, len(tc.messages))
+
+		for _, message := range tc.messages {
+			changes = append(changes, ParseCommitMessage("", message))
+		}
+
+		if bump, reasons := DetectBump(changes, semver.MustParse(tc.version)); bump != tc.expected || len(reasons) != tc.reasons {
+			t.Errorf("%v from %s: expected %s with %d reasons, got %s with %d reasons", tc.messages, tc.version, tc.expected, tc.reasons, bump, len(reasons))
+		}
+	}
+}
+
+func TestDetectChangeBumpIgnoresVersioningCommits(t *testing.T) {
+	for _, message := range []string{
+		"Version 1.2.3.", "Version 1.3.0-beta.1.", "version 1.3.0-rc.0+build.5", "1.2.3", "Updated CHANGELOG.md.",
+		"Prepare for version 1.2.4-SNAPSHOT.",
+	} {
+		if bump := DetectChangeBump(ParseCommitMessage("", message), semver.MustParse("1.2.3")); bump != NoBump {
+			t.Errorf("%q: expected no bump, got %s", message, bump)
+		}
+	}
+}
+
+func TestDetectChangeBumpIgnoresConfiguredCommitMessages(t *testing.T) {
+	previous := configuration.Current.CommitMessages
+	configuration.Current.CommitMessages.Versioning = "chore(release): %s"
+	configuration.Current.CommitMessages.Snapshot = "chore: 100%% ready for %s"
+	configuredCommitFilters = nil
+
+	defer func() {
+		configuration.Current.CommitMessages = previous
+		configuredCommitFilters = nil
+	}()
+
+	cases := []struct {
+		message  string
+		expected string
+	}{
+		{"chore(release): 1.3.0-beta.1", NoBump},
+		{"chore(release): v2.0.0", NoBump},
+		{"chore: 100% ready for 1.2.4-SNAPSHOT", NoBump},
+		{"Prepare for version 1.2.4-SNAPSHOT.", PatchBump},
+		{"fix: 100% ready for review", PatchBump},
+	}
+
+	for _, tc := range cases {
+		if bump := DetectChangeBump(ParseCommitMessage("", tc.message), semver.MustParse("1.2.3")); bump != tc.expected {
+			t.Errorf("%q: expected %s, got %s", tc.message, tc.expected, bump)
+		}
+	}
+}
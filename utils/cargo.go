@@ -0,0 +1,275 @@
+/*
+ * This file is part of impacca. Copyright (C) 2013 and above Shogun <shogun@cowtech.it>.
+ * Licensed under the MIT license, which can be found at https://choosealicense.com/licenses/mit.
+ */
+
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/Masterminds/semver"
+)
+
+var cargoWorkspaceMatcher = regexp.MustCompile("(?ms)^\\[workspace\\]\\s*$(.*?)(?:^\\[|\\z)")
+var cargoMembersMatcher = regexp.MustCompile("(?s)(?:^|\\n)\\s*(members|exclude)\\s*=\\s*\\[(.*?)\\]")
+var cargoStringMatcher = regexp.MustCompile("\"([^\"]*)\"|'([^']*)'")
+var cargoInlineVersionMatcher = regexp.MustCompile("(\\bversion\\s*=\\s*[\"'])([=^~<>]*)[^\"']*([\"'])")
+var cargoInlinePathMatcher = regexp.MustCompile("\\bpath\\s*=")
+var cargoRequirementMatcher = regexp.MustCompile("^[\"']([=^~<>]*)")
+
+// cargoCrate represents a crate of a Cargo workspace
+type cargoCrate struct {
+	Name     string
+	Manifest string
+}
+
+// cargoPackageManager releases Rust crates using cargo
+type cargoPackageManager struct {
+	changedFiles
+}
+
+// tomlValue returns the unquoted value of a key of a TOML file. Keys of tables are prefixed with the table name, like package.name.
+func tomlValue(contents, key string) string {
+	lines, _ := splitLines(contents)
+	table := ""
+
+	for _, line := range lines {
+		if matches := tomlTableMatcher.FindStringSubmatch(line); matches != nil {
+			table = normalizeKey(matches[1])
+			continue
+		}
+
+		matches := tomlKeyMatcher.FindStringSubmatch(line)
+
+		if matches == nil || strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+
+		current := normalizeKey(matches[2])
+
+		if table != "" {
+			current = table + "." + current
+		}
+
+		if current == key {
+			if quoted := quotedValueMatcher.FindStringSubmatch(matches[4]); quoted != nil {
+				return quoted[2]
+			}
+
+			return strings.TrimSpace(bareValueMatcher.ReplaceAllString(matches[4], "$1"))
+		}
+	}
+
+	return ""
+}
+
+// cargoWorkspaceGlobs returns the members and the excluded globs of a Cargo workspace.
+func cargoWorkspaceGlobs(contents string) ([]string, []string) {
+	globs := map[string][]string{"members": {}, "exclude": {}}
+	workspace := cargoWorkspaceMatcher.FindStringSubmatch(contents)
+
+	if workspace == nil {
+		return globs["members"], globs["exclude"]
+	}
+
+	for _, list := range cargoMembersMatcher.FindAllStringSubmatch(workspace[1], -1) {
+		for _, item := range cargoStringMatcher.FindAllStringSubmatch(list[2], -1) {
+			globs[list[1]] = append(globs[list[1]], item[1]+item[2])
+		}
+	}
+
+	return globs["members"], globs["exclude"]
+}
+
+// cargoWorkspaceCrates returns all the crates of the Cargo workspace in a folder, including the root crate, if any.
+func cargoWorkspaceCrates(root string) []cargoCrate {
+	rootManifest := filepath.Join(root, "Cargo.toml")
+	contents := readTextFile(rootManifest)
+	crates := make([]cargoCrate, 0)
+
+	if name := tomlValue(contents, "package.name"); name != "" {
+		crates = append(crates, cargoCrate{Name: name, Manifest: rootManifest})
+	}
+
+	members, excluded := cargoWorkspaceGlobs(contents)
+	skipped := make(map[string]bool)
+
+	for _, glob := range excluded {
+		matches, _ := filepath.Glob(filepath.Join(root, glob))
+
+		for _, match := range matches {
+			skipped[filepath.Clean(match)] = true
+		}
+	}
+
+	for _, glob := range members {
+		matches, err := filepath.Glob(filepath.Join(root, glob))
+
+		if err != nil {
+			Fatal("Invalid workspace members pattern {errorPrimary}%s{-}: {errorPrimary}%s{-}", glob, err.Error())
+		}
+
+		for _, match := range matches {
+			manifest := filepath.Join(match, "Cargo.toml")
+
+			if skipped[filepath.Clean(match)] || manifest == rootManifest {
+				continue
+			} else if _, err := os.Stat(manifest); err != nil {
+				continue
+			}
+
+			crates = append(crates, cargoCrate{Name: tomlValue(readTextFile(manifest), "package.name"), Manifest: manifest})
+		}
+	}
+
+	return crates
+}
+
+// isCargoDependenciesTable checks if a TOML table lists dependencies, including target specific ones.
+func isCargoDependenciesTable(table string) bool {
+	return strings.HasSuffix(table, "dependencies")
+}
+
+// cargoRequirement returns a version requirement for a version, preserving the operator of the current requirement.
+func cargoRequirement(value, version string) string {
+	if matches := cargoRequirementMatcher.FindStringSubmatch(value); matches != nil {
+		return matches[1] + version
+	}
+
+	return version
+}
+
+// replaceCargoVersions replaces the version of the crate, of the workspace and of all dependencies on crates of the workspace.
+// Formatting and comments are preserved.
+func replaceCargoVersions(contents string, crates map[string]bool, version string) string {
+	lines, endings := splitLines(contents)
+	table := ""
+
+	for i, line := range lines {
+		if matches := tomlTableMatcher.FindStringSubmatch(line); matches != nil {
+			table = normalizeKey(matches[1])
+			continue
+		}
+
+		matches := tomlKeyMatcher.FindStringSubmatch(line)
+
+		if matches == nil || strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+
+		key := normalizeKey(matches[2])
+		value := matches[4]
+		replacement := ""
+
+		// Versions inherited from the workspace, like version = { workspace = true }, are left untouched
+		inlineTable := strings.HasPrefix(value, "{")
+
+		// Dependencies can either be inline tables, like name = { path = "...", version = "..." }, or tables, like [dependencies.name]
+		tableParent := ""
+		tableName := table
+
+		if separator := strings.LastIndex(table, "."); separator != -1 {
+			tableParent, tableName = table[:separator], table[separator+1:]
+		}
+
+		switch {
+		case (table == "package" || table == "workspace.package") && key == "version" && !inlineTable:
+			replacement = replaceScalar(value, version)
+		case isCargoDependenciesTable(table) && crates[key] && inlineTable && cargoInlinePathMatcher.MatchString(value):
+			replacement = cargoInlineVersionMatcher.ReplaceAllString(value, "${1}${2}"+version+"${3}")
+		case isCargoDependenciesTable(tableParent) && crates[tableName] && key == "version" && !inlineTable:
+			replacement = replaceScalar(value, cargoRequirement(value, version))
+		}
+
+		if replacement != "" {
+			lines[i] = strings.TrimSuffix(line, value) + replacement
+		}
+	}
+
+	return joinLines(lines, endings)
+}
+
+func (m *cargoPackageManager) Name() string {
+	return "cargo"
+}
+
+func (m *cargoPackageManager) Detect(folder string) bool {
+	_, err := os.Stat(filepath.Join(folder, "Cargo.toml"))
+	return err == nil
+}
+
+func (m *cargoPackageManager) CurrentVersion() string {
+	cwd, _ := os.Getwd()
+	contents := readTextFile(filepath.Join(cwd, "Cargo.toml"))
+
+	if version := tomlValue(contents, "package.version"); version != "" && !strings.HasPrefix(version, "{") {
+		return version
+	}
+
+	return tomlValue(contents, "workspace.package.version")
+}
+
+// SetVersion updates the version of a crate or of a Cargo workspace, refreshing the Cargo.lock file.
+func (m *cargoPackageManager) SetVersion(newVersion, currentVersion *semver.Version, dryRun bool) {
+	cwd, _ := os.Getwd()
+	m.resetFiles()
+
+	version := FormatVersion(newVersion)
+	crates := cargoWorkspaceCrates(cwd)
+	names := make(map[string]bool)
+
+	for _, crate := range crates {
+		names[crate.Name] = true
+	}
+
+	manifests := []string{filepath.Join(cwd, "Cargo.toml")}
+	for _, crate := range crates {
+		if crate.Manifest != manifests[0] {
+			manifests = append(manifests, crate.Manifest)
+		}
+	}
+
+	for _, manifest := range manifests {
+		relative, _ := filepath.Rel(cwd, manifest)
+		previous := readTextFile(manifest)
+		updated := replaceCargoVersions(previous, names, version)
+
+		if updated != previous {
+			writeUpdatedFile(manifest, relative, previous, updated, dryRun)
+			m.trackFile(manifest)
+		}
+	}
+
+	// Only the crates of the workspace are updated in Cargo.lock, dependencies are left untouched
+	if _, err := os.Stat(filepath.Join(cwd, "Cargo.lock")); err == nil {
+		if NotifyExecution(dryRun, "Will execute", "Executing", ": {primary}cargo update --workspace{-} ...") {
+			result := Execute(true, "cargo", "update", "--workspace")
+			result.Verify("cargo", "Cannot update Cargo.lock")
+		}
+
+		m.trackFile(filepath.Join(cwd, "Cargo.lock"))
+	}
+}
+
+// Publish publishes the crate or, for workspaces, all the crates in dependency order.
+// cargo can verify crates without publishing them, so in dry-run mode it is executed anyway.
+func (m *cargoPackageManager) Publish(version *semver.Version, options PublishOptions) {
+	cwd, _ := os.Getwd()
+	publishArgs := []string{"publish"}
+
+	if len(cargoWorkspaceCrates(cwd)) > 1 {
+		publishArgs = append(publishArgs, "--workspace")
+	}
+
+	if options.DryRun {
+		publishArgs = append(publishArgs, "--dry-run")
+	}
+
+	NotifyExecution(false, "", "Executing", ": {primary}cargo %s{-} ...", strings.Join(publishArgs, " "))
+	result := Execute(true, "cargo", publishArgs...)
+	result.Verify("cargo", "Cannot publish the crate")
+}
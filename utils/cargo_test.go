@@ -0,0 +1,170 @@
+/*
+ * This file is part of impacca. Copyright (C) 2013 and above Shogun <shogun@cowtech.it>.
+ * Licensed under the MIT license, which can be found at https://choosealicense.com/licenses/mit.
+ */
+
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Masterminds/semver"
+	"github.com/ShogunPanda/impacca/configuration"
+)
+
+func TestTomlValue(t *testing.T) {
+	contents := "name = \"root\"\n\n[package]\n# name = \"commented\"\nname = \"app\" # The crate name\nversion = '1.2.3'\nedition = 2021\n\n[dependencies]\nname = \"dependency\"\n"
+
+	cases := map[string]string{
+		"name": "root", "package.name": "app", "package.version": "1.2.3", "package.edition": "2021", "dependencies.name": "dependency",
+		"package.missing": "",
+	}
+
+	for key, expected := range cases {
+		if actual := tomlValue(contents, key); actual != expected {
+			t.Errorf("%s: expected %q, got %q", key, expected, actual)
+		}
+	}
+}
+
+func TestCargoWorkspaceCrates(t *testing.T) {
+	defer useTestFolder(t, map[string]string{
+		"Cargo.toml":                     "[package]\nname = \"app\"\n\n[workspace]\nmembers = [\n  \"crates/*\",\n  'tools',\n]\nexclude = [\"crates/experimental\"]\n\n[dependencies]\n",
+		"crates/core/Cargo.toml":         "[package]\nname = \"app-core\"\n",
+		"crates/cli/Cargo.toml":          "[package]\nname = \"app-cli\"\n",
+		"crates/experimental/Cargo.toml": "[package]\nname = \"app-experimental\"\n",
+		"crates/docs/README.md":          "Not a crate.\n",
+		"tools/Cargo.toml":               "[package]\nname = \"app-tools\"\n",
+	})()
+
+	cwd, _ := os.Getwd()
+	names := make([]string, 0)
+
+	for _, crate := range cargoWorkspaceCrates(cwd) {
+		if relative, _ := filepath.Rel(cwd, crate.Manifest); filepath.Base(relative) != "Cargo.toml" {
+			t.Errorf("unexpected manifest %s", crate.Manifest)
+		}
+
+		names = append(names, crate.Name)
+	}
+
+	if expected := []string{"app", "app-cli", "app-core", "app-tools"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected crates %v, got %v", expected, names)
+	}
+}
+
+func TestReplaceCargoVersions(t *testing.T) {
+	crates := map[string]bool{"app": true, "app-core": true, "app-cli": true}
+
+	cases := []struct {
+		name     string
+		contents string
+		expected string
+	}{
+		{
+			"package",
+			"[package]\nname = \"app\"\nversion = \"1.2.3\" # Managed by impacca\n",
+			"[package]\nname = \"app\"\nversion = \"2.0.0\" # Managed by impacca\n",
+		},
+		{
+			"workspace package",
+			"[workspace.package]\nversion = '1.2.3'\n\n[workspace.dependencies]\nserde = \"1.0\"\n",
+			"[workspace.package]\nversion = '2.0.0'\n\n[workspace.dependencies]\nserde = \"1.0\"\n",
+		},
+		{
+			"inline dependencies",
+			"[dependencies]\napp-core = { path = \"../core\", version = \"^1.2.3\" }\nserde = { version = \"1.0\" }\nregistry = { version = \"1.2.3\" }\n" +
+				"\n[dev-dependencies]\napp-cli = { version = \"=1.2.3\", path = \"../cli\" }\n",
+			"[dependencies]\napp-core = { path = \"../core\", version = \"^2.0.0\" }\nserde = { version = \"1.0\" }\nregistry = { version = \"1.2.3\" }\n" +
+				"\n[dev-dependencies]\napp-cli = { version = \"=2.0.0\", path = \"../cli\" }\n",
+		},
+		{
+			"dependency tables",
+			"[dependencies.app-core]\npath = \"../core\"\nversion = \"~1.2.3\"\n\n[target.'cfg(unix)'.dependencies.app-cli]\nversion = \"1.2.3\"\n\n[dependencies.serde]\nversion = \"1.0\"\n",
+			"[dependencies.app-core]\npath = \"../core\"\nversion = \"~2.0.0\"\n\n[target.'cfg(unix)'.dependencies.app-cli]\nversion = \"2.0.0\"\n\n[dependencies.serde]\nversion = \"1.0\"\n",
+		},
+		{
+			"other tables",
+			"[package.metadata]\nversion = \"1.2.3\"\n\n[features]\n# version = \"1.2.3\"\ndefault = []\n",
+			"[package.metadata]\nversion = \"1.2.3\"\n\n[features]\n# version = \"1.2.3\"\ndefault = []\n",
+		},
+		{
+			"inherited versions",
+			"[package]\nname = \"app-cli\"\nversion = { workspace = true }\n\n[dependencies]\napp-core = { workspace = true }\n" +
+				"\n[dependencies.app]\nversion = { workspace = true }\n",
+			"[package]\nname = \"app-cli\"\nversion = { workspace = true }\n\n[dependencies]\napp-core = { workspace = true }\n" +
+				"\n[dependencies.app]\nversion = { workspace = true }\n",
+		},
+		{
+			"inherited versions with dotted keys",
+			"[package]\nname = \"app-cli\"\nversion.workspace = true\n\n[dependencies]\napp-core.workspace = true\n",
+			"[package]\nname = \"app-cli\"\nversion.workspace = true\n\n[dependencies]\napp-core.workspace = true\n",
+		},
+		{
+			"line endings",
+			"[package]\r\nversion = \"1.2.3\"\r\n",
+			"[package]\r\nversion = \"2.0.0\"\r\n",
+		},
+	}
+
+	for _, tc := range cases {
+		if actual := replaceCargoVersions(tc.contents, crates, "2.0.0"); actual != tc.expected {
+			t.Errorf("%s: unexpected manifest:\n%s", tc.name, actual)
+		}
+	}
+}
+
+func TestCargoCurrentVersion(t *testing.T) {
+	cases := map[string]string{
+		"[package]\nname = \"app\"\nversion = \"1.2.3\"\n":                                                        "1.2.3",
+		"[workspace]\nmembers = []\n\n[workspace.package]\nversion = \"1.2.3\"\n":                                 "1.2.3",
+		"[package]\nname = \"app\"\nversion = { workspace = true }\n\n[workspace.package]\nversion = \"1.2.3\"\n": "1.2.3",
+		"[package]\nname = \"app\"\nversion.workspace = true\n\n[workspace.package]\nversion = \"1.2.3\"\n":       "1.2.3",
+	}
+
+	for contents, expected := range cases {
+		done := useTestFolder(t, map[string]string{"Cargo.toml": contents})
+
+		if actual := (&cargoPackageManager{}).CurrentVersion(); actual != expected {
+			t.Errorf("%q: expected %s, got %s", contents, expected, actual)
+		}
+
+		done()
+	}
+}
+
+func TestReleaseCargoVersion(t *testing.T) {
+	defer useTestGitRepository(t, "v1.2.3")()
+
+	writeTestFiles(t, map[string]string{
+		"Cargo.toml":             "[workspace]\nmembers = [\"crates/*\"]\n\n[workspace.package]\nversion = \"1.2.3\"\n",
+		"crates/cli/Cargo.toml":  "[package]\nname = \"app-cli\"\nversion = \"1.2.3\"\n\n[dependencies]\napp-core = { path = \"../core\", version = \"1.2.3\" }\n",
+		"crates/core/Cargo.toml": "[package]\nname = \"app-core\"\nversion = \"1.2.3\"\n",
+	})
+
+	runTestGit(t, "add", "--all")
+	runTestGit(t, "commit", "--quiet", "--message=feat: Initial workspace")
+
+	previous := configuration.Current.VersionFiles
+	configuration.Current.VersionFiles = []configuration.VersionFile{}
+	defer func() { configuration.Current.VersionFiles = previous }()
+
+	ReleaseVersion(&cargoPackageManager{}, semver.MustParse("1.3.0"), semver.MustParse("1.2.3"), false)
+
+	expected := map[string]string{
+		"Cargo.toml":             "[workspace]\nmembers = [\"crates/*\"]\n\n[workspace.package]\nversion = \"1.3.0\"\n",
+		"crates/cli/Cargo.toml":  "[package]\nname = \"app-cli\"\nversion = \"1.3.0\"\n\n[dependencies]\napp-core = { path = \"../core\", version = \"1.3.0\" }\n",
+		"crates/core/Cargo.toml": "[package]\nname = \"app-core\"\nversion = \"1.3.0\"\n",
+	}
+
+	if actual := readTestFiles(t, "Cargo.toml", "crates/cli/Cargo.toml", "crates/core/Cargo.toml"); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("unexpected manifests: %v", actual)
+	}
+
+	if status := runTestGit(t, "status", "--porcelain"); status != "" || runTestGit(t, "log", "-n", "1", "--format=%s", "v1.3.0") != "Version 1.3.0." {
+		t.Errorf("the version has not been committed and tagged: %s", status)
+	}
+}
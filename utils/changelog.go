@@ -0,0 +1,248 @@
+/*
+ * This file is part of impacca. Copyright (C) 2013 and above Shogun <shogun@cowtech.it>.
+ * Licensed under the MIT license, which can be found at https://choosealicense.com/licenses/mit.
+ */
+
+package utils
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver"
+)
+
+// Version headings supported:
+//
+//	impacca:                ### 2020-01-29 / 2.0.5
+//	Keep a Changelog:       ## [2.0.5] - 2020-01-29
+//	conventional-changelog: ## [2.0.5](https://github.com/owner/repo/compare/v2.0.4...v2.0.5) (2020-01-29)
+//	plain:                  # v2.0.5 (2020-01-29)
+var changelogHeadingMatcher = regexp.MustCompile("^(#{1,4})\\s+(.+?)\\s*$")
+var changelogVersionMatcher = regexp.MustCompile("(?:^|[\\s\\[/(])v?(\\d+\\.\\d+(?:\\.\\d+)?(?:-[0-9A-Za-z.-]+)?(?:\\+[0-9A-Za-z.-]+)?)(?:$|[\\s\\])])")
+var changelogDateMatcher = regexp.MustCompile("\\b(\\d{4}-\\d{2}-\\d{2})\\b")
+var changelogUnreleasedMatcher = regexp.MustCompile("(?i)^\\[?unreleased\\]?")
+var changelogEntryMatcher = regexp.MustCompile("^[-*+]\\s+(.*)$")
+var changelogLinkMatcher = regexp.MustCompile("^\\[[^\\]]+\\]:\\s*\\S+\\s*$")
+
+// ChangelogEntry represents a single entry of a CHANGELOG.md version section
+type ChangelogEntry struct {
+	Group string
+	Text  string
+}
+
+// ChangelogSection represents a version section of a CHANGELOG.md file.
+// Header and Body are kept verbatim, including the trailing newlines.
+type ChangelogSection struct {
+	Version    string
+	Date       string
+	Unreleased bool
+	Level      int
+	Line       int
+	Header     string
+	Body       string
+	Entries    []ChangelogEntry
+}
+
+// Changelog represents a parsed CHANGELOG.md file. Printing it returns the original contents.
+type Changelog struct {
+	Preamble string
+	Sections []*ChangelogSection
+	Epilogue string
+}
+
+// Raw returns the section, verbatim.
+func (s *ChangelogSection) Raw() string {
+	return s.Header + s.Body
+}
+
+// Content returns the section without the header and with whitespaces trimmed.
+func (s *ChangelogSection) Content() string {
+	return strings.TrimSpace(s.Body)
+}
+
+func parseChangelogHeading(line string) (level int, version, date string, unreleased bool) {
+	matches := changelogHeadingMatcher.FindStringSubmatch(strings.TrimRight(line, "\r\n"))
+
+	if matches == nil {
+		return 0, "", "", false
+	}
+
+	level = len(matches[1])
+
+	if changelogUnreleasedMatcher.MatchString(matches[2]) {
+		return level, "", "", true
+	}
+
+	versionMatches := changelogVersionMatcher.FindStringSubmatch(matches[2])
+
+	if versionMatches == nil {
+		return 0, "", "", false
+	}
+
+	if dateMatches := changelogDateMatcher.FindStringSubmatch(matches[2]); dateMatches != nil {
+		date = dateMatches[1]
+	}
+
+	return level, versionMatches[1], date, false
+}
+
+// ParseChangelog parses a CHANGELOG.md file in the impacca, Keep a Changelog or conventional-changelog formats.
+func ParseChangelog(contents string) *Changelog {
+	changelog := &Changelog{Sections: make([]*ChangelogSection, 0)}
+	lines := strings.SplitAfter(contents, "\n")
+
+	// Link references at the end of the file are not part of the last section
+	epilogueStart := len(lines)
+	for i := len(lines) - 1; i >= 0; i-- {
+		trimmed := strings.TrimSpace(lines[i])
+
+		if trimmed != "" && !changelogLinkMatcher.MatchString(trimmed) {
+			break
+		}
+
+		if trimmed != "" {
+			epilogueStart = i
+		}
+	}
+
+	changelog.Epilogue = strings.Join(lines[epilogueStart:], "")
+	lines = lines[:epilogueStart]
+
+	// The level of the first version heading is used for all other versions.
+	// conventional-changelog uses one more level for patch versions, so allow it
+	versionsLevel := 0
+	var section *ChangelogSection
+	group := ""
+
+	for i, line := range lines {
+		level, version, date, unreleased := parseChangelogHeading(line)
+
+		if level > 0 && (versionsLevel == 0 || level <= versionsLevel+1) {
+			if versionsLevel == 0 {
+				versionsLevel = level
+			}
+
+			group = ""
+			section = &ChangelogSection{
+				Version: version, Date: date, Unreleased: unreleased, Level: level, Line: i + 1, Header: line,
+				Entries: make([]ChangelogEntry, 0),
+			}
+
+			changelog.Sections = append(changelog.Sections, section)
+			continue
+		}
+
+		if section == nil {
+			changelog.Preamble += line
+			continue
+		}
+
+		section.Body += line
+		trimmed := strings.TrimRight(line, "\r\n")
+
+		if matches := changelogHeadingMatcher.FindStringSubmatch(trimmed); matches != nil {
+			group = matches[2]
+		} else if matches := changelogEntryMatcher.FindStringSubmatch(trimmed); matches != nil {
+			section.Entries = append(section.Entries, ChangelogEntry{Group: group, Text: strings.TrimSpace(matches[1])})
+		} else if strings.TrimSpace(trimmed) != "" && (strings.HasPrefix(trimmed, " ") || strings.HasPrefix(trimmed, "\t")) {
+			// Continuation of the previous entry
+			if len(section.Entries) > 0 {
+				last := &section.Entries[len(section.Entries)-1]
+				last.Text += "\n" + strings.TrimSpace(trimmed)
+			}
+		}
+	}
+
+	return changelog
+}
+
+// String returns the CHANGELOG.md contents.
+func (c *Changelog) String() string {
+	var builder strings.Builder
+
+	builder.WriteString(c.Preamble)
+
+	for _, section := range c.Sections {
+		builder.WriteString(section.Raw())
+	}
+
+	builder.WriteString(c.Epilogue)
+	return builder.String()
+}
+
+// Find finds the section of a version, if any. Versions are compared by value, so 2020.01 matches 2020.1.
+func (c *Changelog) Find(version string) *ChangelogSection {
+	version = strings.TrimPrefix(version, "v")
+	parsed, err := semver.NewVersion(version)
+
+	for _, section := range c.Sections {
+		if section.Unreleased {
+			continue
+		} else if section.Version == version {
+			return section
+		} else if other, otherErr := semver.NewVersion(section.Version); err == nil && otherErr == nil && other.Equal(parsed) {
+			return section
+		}
+	}
+
+	return nil
+}
+
+// Versions returns all the versions in the file, in the file order.
+func (c *Changelog) Versions() []string {
+	versions := make([]string, 0)
+
+	for _, section := range c.Sections {
+		if !section.Unreleased {
+			versions = append(versions, section.Version)
+		}
+	}
+
+	return versions
+}
+
+// ReadParsedChangelog reads and parses the current CHANGELOG.md file.
+func ReadParsedChangelog() *Changelog {
+	return ParseChangelog(ReadChangelog())
+}
+
+// MergeChangelogSections builds a CHANGELOG.md from generated sections, keeping the existing sections when present.
+// Generated sections are keyed by version. Existing sections of versions without a tag are kept at the end.
+func MergeChangelogSections(existing *Changelog, generated map[string]string, versions semver.Collection) string {
+	var builder strings.Builder
+	used := make(map[*ChangelogSection]bool)
+
+	builder.WriteString(existing.Preamble)
+
+	// Keep the Unreleased section on top
+	for _, section := range existing.Sections {
+		if section.Unreleased {
+			builder.WriteString(strings.TrimRight(section.Raw(), "\r\n") + "\n\n")
+			used[section] = true
+		}
+	}
+
+	sorted := make(semver.Collection, len(versions))
+	copy(sorted, versions)
+	sort.Sort(sort.Reverse(sorted))
+
+	for _, version := range sorted {
+		if section := existing.Find(FormatVersion(version)); section != nil {
+			builder.WriteString(strings.TrimRight(section.Raw(), "\r\n") + "\n\n")
+			used[section] = true
+		} else {
+			builder.WriteString(strings.TrimSpace(generated[version.String()]) + "\n\n")
+		}
+	}
+
+	for _, section := range existing.Sections {
+		if !used[section] {
+			builder.WriteString(strings.TrimRight(section.Raw(), "\r\n") + "\n\n")
+		}
+	}
+
+	builder.WriteString(existing.Epilogue)
+	return strings.TrimSpace(builder.String()) + "\n"
+}
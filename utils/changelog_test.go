@@ -0,0 +1,141 @@
+/*
+ * This file is part of impacca. Copyright (C) 2013 and above Shogun <shogun@cowtech.it>.
+ * Licensed under the MIT license, which can be found at https://choosealicense.com/licenses/mit.
+ */
+
+package utils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Masterminds/semver"
+)
+
+func TestParseChangelog(t *testing.T) {
+	cases := []struct {
+		name     string
+		contents string
+		versions []string
+		dates    []string
+		entries  []ChangelogEntry
+	}{
+		{
+			"impacca",
+			"### 2020-01-29 / 2.0.5\n\n- fix: Crash (abc)\n- Multiline\n  entry\n\n### 2020-01-01 / 2.0.4\n\n- feat: Arrays\n",
+			[]string{"2.0.5", "2.0.4"}, []string{"2020-01-29", "2020-01-01"},
+			[]ChangelogEntry{{Text: "fix: Crash (abc)"}, {Text: "Multiline\nentry"}},
+		},
+		{
+			"keepachangelog",
+			"# Changelog\n\nNotes.\n\n## [Unreleased]\n\n## [2.0.5] - 2020-01-29\n\n### Fixed\n\n- Crash\n\n## [2.0.4] - 2020-01-01\n\n" +
+				"[Unreleased]: https://example.com/compare/v2.0.5...HEAD\n[2.0.5]: https://example.com/compare/v2.0.4...v2.0.5\n",
+			[]string{"2.0.5", "2.0.4"}, []string{"2020-01-29", "2020-01-01"},
+			[]ChangelogEntry{{Group: "Fixed", Text: "Crash"}},
+		},
+		{
+			"conventional-changelog",
+			"## [2.1.0](https://github.com/owner/repo/compare/v2.0.4...v2.1.0) (2020-01-29)\n\n### Features\n\n* **lexer:** Objects\n\n" +
+				"### [2.0.4](https://github.com/owner/repo/compare/v2.0.3...v2.0.4) (2020-01-01)\n\n* Crash\n",
+			[]string{"2.1.0", "2.0.4"}, []string{"2020-01-29", "2020-01-01"},
+			[]ChangelogEntry{{Group: "Features", Text: "**lexer:** Objects"}},
+		},
+		{
+			"plain",
+			"# Project\n\n# v2.0.5 (2020-01-29)\n\n## Notes\n\n+ Crash\n\n# v2.0.4-beta.1\n\nFirst beta.\n",
+			[]string{"2.0.5", "2.0.4-beta.1"}, []string{"2020-01-29", ""},
+			[]ChangelogEntry{{Group: "Notes", Text: "Crash"}},
+		},
+	}
+
+	for _, tc := range cases {
+		changelog := ParseChangelog(tc.contents)
+
+		if actual := changelog.String(); actual != tc.contents {
+			t.Errorf("%s: contents not preserved:\n%s", tc.name, actual)
+		}
+
+		if versions := changelog.Versions(); !reflect.DeepEqual(versions, tc.versions) {
+			t.Errorf("%s: expected versions %v, got %v", tc.name, tc.versions, versions)
+		}
+
+		for i, version := range tc.versions {
+			section := changelog.Find("v" + version)
+
+			if section == nil || section.Date != tc.dates[i] {
+				t.Errorf("%s: unexpected section for %s: %+v", tc.name, version, section)
+			}
+		}
+
+		if entries := changelog.Find(tc.versions[0]).Entries; !reflect.DeepEqual(entries, tc.entries) {
+			t.Errorf("%s: expected entries %v, got %v", tc.name, tc.entries, entries)
+		}
+	}
+}
+
+func TestChangelogSectionContent(t *testing.T) {
+	changelog := ParseChangelog("# Changelog\n\n## [Unreleased]\n\n- Work in progress\n\n## [1.0.0] - 2020-01-01\n\n- First release\n\n[1.0.0]: https://example.com\n")
+
+	if len(changelog.Sections) != 2 || !changelog.Sections[0].Unreleased || changelog.Preamble != "# Changelog\n\n" {
+		t.Fatalf("unexpected sections: %+v", changelog.Sections)
+	}
+
+	if section := changelog.Find("1.0.0"); section.Content() != "- First release" || section.Line != 7 {
+		t.Errorf("unexpected section: %+v", section)
+	}
+
+	if changelog.Epilogue != "[1.0.0]: https://example.com\n" || changelog.Find("2.0.0") != nil {
+		t.Errorf("unexpected epilogue: %q", changelog.Epilogue)
+	}
+}
+
+func TestMergeChangelogSections(t *testing.T) {
+	existing := ParseChangelog("# Changelog\n\n## Unreleased\n\n- Next\n\n### 2020-01-02 / 1.1.0\n\n- Edited by hand\n\n### 2019-01-01 / 0.9.0\n\n- Untagged\n")
+	generated := map[string]string{"1.2.0": "### 2020-02-01 / 1.2.0\n\n- Generated\n", "1.0.0": "### 2020-01-01 / 1.0.0\n\n- Generated\n"}
+	versions := semver.Collection{semver.MustParse("1.0.0"), semver.MustParse("1.2.0"), semver.MustParse("1.1.0")}
+
+	expected := "# Changelog\n\n## Unreleased\n\n- Next\n\n" +
+		"### 2020-02-01 / 1.2.0\n\n- Generated\n\n" +
+		"### 2020-01-02 / 1.1.0\n\n- Edited by hand\n\n" +
+		"### 2020-01-01 / 1.0.0\n\n- Generated\n\n" +
+		"### 2019-01-01 / 0.9.0\n\n- Untagged\n"
+
+	if actual := MergeChangelogSections(existing, generated, versions); actual != expected {
+		t.Errorf("unexpected changelog:\n%s", actual)
+	}
+
+	if versions[0].String() != "1.0.0" {
+		t.Errorf("the versions have been modified: %v", versions)
+	}
+}
+
+func TestSaveChanges(t *testing.T) {
+	defer useTestGitRepository(t)()
+	defer useTestChangelogRepository(nil)()
+
+	WriteChangelog("### 2020-01-01 / 1.0.0\n\n- First release\n")
+	SaveChanges(semver.MustParse("1.1.0"), semver.MustParse("1.0.0"), parseTestChanges("feat: Arrays"), false)
+
+	changelog := ParseChangelog(ReadChangelog())
+
+	if !reflect.DeepEqual(changelog.Versions(), []string{"1.1.0", "1.0.0"}) || !strings.Contains(changelog.Sections[0].Content(), "- feat: Arrays") {
+		t.Errorf("unexpected changelog:\n%s", changelog.String())
+	}
+
+	if result := Execute(false, "git", "status", "--porcelain"); result.Stdout != "" {
+		t.Errorf("the changelog has not been committed: %s", result.Stdout)
+	}
+}
+
+func TestSaveChangesRefusesExistingVersions(t *testing.T) {
+	defer useTestFolder(t, map[string]string{"CHANGELOG.md": "### 2020-01-01 / 1.0.0\n\n- First release\n"})()
+
+	expectFatal(t, "is already present in the CHANGELOG.md file", func() {
+		SaveChanges(semver.MustParse("1.0.0"), semver.MustParse("0.9.0"), parseTestChanges("feat: Arrays"), false)
+	})
+
+	if changelog := ReadChangelog(); changelog != "### 2020-01-01 / 1.0.0\n\n- First release\n" {
+		t.Errorf("the changelog has been modified:\n%s", changelog)
+	}
+}
@@ -20,18 +20,167 @@ import (
 
 var commitChecker = regexp.MustCompile("^[a-f0-9]+$")
 var updateChangelogCommitFilter = regexp.MustCompile("(?i)^(?:(update(?:[ds])? changelog(?:\\.md)?(?:.)?))$")
-var versionTagCommitFilter = regexp.MustCompile("(?i)^(?:version\\s+\\d+\\.\\d+\\.\\d+(?:.)?)$")
+var versionTagCommitFilter = regexp.MustCompile("(?i)^(?:version\\s+\\d+\\.\\d+\\.\\d+(?:-[0-9a-z.-]+)?(?:\\+[0-9a-z.-]+)?(?:.)?)$")
 
-// Change represents a git commit
-type Change struct {
-	Hash    string
-	Message string
-	Type    string
+const commitFieldSeparator = "\x1f"
+const commitRecordSeparator = "\x1e"
+
+var changelogRepository *Repository
+var changelogRepositoryDetected bool
+
+var configuredCommitFilters []*regexp.Regexp
+
+// commitMessageFilters returns the matchers of the commits created by impacca using the configured messages.
+// In the messages, %s is a version.
+func commitMessageFilters() []*regexp.Regexp {
+	if configuredCommitFilters == nil {
+		messages := configuration.Current.CommitMessages
+		replacer := strings.NewReplacer("%s", "v?\\d[0-9A-Za-z.+-]*", "%%", "%")
+
+		for _, message := range []string{messages.Versioning, messages.Changelog, messages.Snapshot} {
+			if message = strings.TrimSpace(message); message != "" {
+				configuredCommitFilters = append(configuredCommitFilters, regexp.MustCompile("(?i)^"+replacer.Replace(regexp.QuoteMeta(message))+"$"))
+			}
+		}
+	}
+
+	return configuredCommitFilters
 }
 
 func filterCommit(change Change) bool {
-	_, err := semver.NewVersion(change.Message)
-	return updateChangelogCommitFilter.MatchString(change.Message) || versionTagCommitFilter.MatchString(change.Message) || err == nil
+	if _, err := semver.NewVersion(change.Message); err == nil {
+		return true
+	} else if updateChangelogCommitFilter.MatchString(change.Message) || versionTagCommitFilter.MatchString(change.Message) {
+		return true
+	}
+
+	for _, filter := range commitMessageFilters() {
+		if filter.MatchString(change.Summary()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ChangesGroup represents a list of changes under a changelog heading
+type ChangesGroup struct {
+	Title   string
+	Changes []Change
+}
+
+func matchChangelogSection(section configuration.ChangelogSection, change Change, catchAll bool) bool {
+	for _, sectionType := range section.Types {
+		switch sectionType {
+		case "!":
+			if change.Breaking {
+				return true
+			}
+		case "*":
+			if catchAll {
+				return true
+			}
+		default:
+			if strings.EqualFold(sectionType, change.Type) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func isHiddenChange(change Change) bool {
+	if change.Breaking {
+		return false
+	}
+
+	for _, hidden := range configuration.Current.Changelog.HiddenTypes {
+		if strings.EqualFold(hidden, change.Type) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GroupChanges groups changes according to the configured changelog sections, filtering hidden ones.
+// When grouping is disabled, a single group without title is returned.
+func GroupChanges(changes []Change) []ChangesGroup {
+	settings := configuration.Current.Changelog
+	visible := make([]Change, 0)
+
+	for _, change := range changes {
+		// Filter some commits
+		if !filterCommit(change) && !isHiddenChange(change) {
+			visible = append(visible, change)
+		}
+	}
+
+	if !settings.Grouped || len(settings.Sections) == 0 {
+		return []ChangesGroup{{Title: "", Changes: visible}}
+	}
+
+	groups := make([]ChangesGroup, len(settings.Sections))
+	for i, section := range settings.Sections {
+		groups[i].Title = section.Title
+	}
+
+	for _, change := range visible {
+		// First of all, look for an explicit match, then fallback to catch-all sections
+		assigned := -1
+
+		for _, catchAll := range []bool{false, true} {
+			for i, section := range settings.Sections {
+				if matchChangelogSection(section, change, catchAll) {
+					assigned = i
+					break
+				}
+			}
+
+			if assigned != -1 {
+				break
+			}
+		}
+
+		if assigned != -1 {
+			groups[assigned].Changes = append(groups[assigned].Changes, change)
+		}
+	}
+
+	// Remove empty groups
+	nonEmpty := make([]ChangesGroup, 0)
+	for _, group := range groups {
+		if len(group.Changes) > 0 {
+			nonEmpty = append(nonEmpty, group)
+		}
+	}
+
+	return nonEmpty
+}
+
+// FormatChangeEntry formats a change as a changelog entry.
+// When changes are grouped, the type is omitted as it is already expressed by the heading.
+func FormatChangeEntry(change Change, grouped bool) string {
+	if !grouped || change.Type == "" {
+		return change.Summary()
+	}
+
+	if change.Scope != "" {
+		return fmt.Sprintf("%s: %s", change.Scope, change.Message)
+	}
+
+	return change.Message
+}
+
+// detectChangelogRepository detects the repository of the origin remote, if any.
+func detectChangelogRepository() *Repository {
+	if !changelogRepositoryDetected {
+		changelogRepository = DetectRepository("origin", true)
+		changelogRepositoryDetected = true
+	}
+
+	return changelogRepository
 }
 
 // GetFirstCommitHash gets the first commit hash
@@ -47,7 +196,7 @@ func ListChanges(version, previousVersion string) []Change {
 	// Get the current version
 	if version == "" {
 		versions := GetVersions()
-		version = versions[len(versions)-1].String()
+		version = FormatVersion(versions[len(versions)-1])
 	}
 
 	if previousVersion == "" {
@@ -55,128 +204,112 @@ func ListChanges(version, previousVersion string) []Change {
 	}
 
 	if version != "HEAD" && !commitChecker.MatchString(version) {
-		version = fmt.Sprintf("v%s", version)
+		version = VersionTag(version)
 	}
 
 	if previousVersion != "HEAD" && !commitChecker.MatchString(previousVersion) {
-		previousVersion = fmt.Sprintf("v%s", previousVersion)
+		previousVersion = VersionTag(previousVersion)
 	}
 
-	// Get the list of changes
-	executionArgs := []string{"log", "--format=%h %s"}
+	// Get the list of changes - Use ASCII unit and record separators so that multiline bodies are preserved
+	executionArgs := []string{
+		"log", fmt.Sprintf("--format=%%h%[1]s%%an%[1]s%%ae%[1]s%%B%[2]s", commitFieldSeparator, commitRecordSeparator),
+	}
 
-	if version != "0.0.0" {
+	if version != VersionTag(FormatVersion(initialVersion())) {
 		executionArgs = append(executionArgs, fmt.Sprintf("%s...%s", previousVersion, version))
 	}
 
+	// Inside a monorepo, only consider commits touching the package folder
+	if CurrentPackage != nil {
+		executionArgs = append(executionArgs, "--", ".")
+	}
+
 	result := Execute(false, "git", executionArgs...)
 	result.Verify("git", "Cannot list GIT changes")
 
-	rawChanges := strings.Split(strings.TrimSpace(result.Stdout), "\n")
+	rawChanges := strings.Split(result.Stdout, commitRecordSeparator)
 	changes := make([]Change, 0)
 
 	for _, change := range rawChanges {
+		change = strings.TrimSpace(change)
+
 		if change == "" {
 			continue
 		}
 
-		changeTokens := strings.SplitN(change, " ", 2)
-		messageComponents := []string{"feat", changeTokens[1]}
+		changeTokens := strings.SplitN(change, commitFieldSeparator, 4)
 
-		if strings.Index(messageComponents[1], ":") != -1 {
-			messageComponents = strings.SplitN(changeTokens[1], ":", 2)
-		} else if strings.Index(messageComponents[1], "fix") != -1 {
-			messageComponents[0] = "fix"
+		if len(changeTokens) != 4 {
+			continue
 		}
 
-		changes = append(
-			changes,
-			Change{
-				strings.ToLower(strings.TrimSpace(changeTokens[0])),
-				strings.TrimSpace(messageComponents[1]),
-				strings.ToLower(strings.TrimSpace(messageComponents[0])),
-			},
-		)
+		parsed := ParseCommitMessage(strings.TrimSpace(changeTokens[0]), changeTokens[3])
+		parsed.Author = changeTokens[1]
+		parsed.AuthorEmail = changeTokens[2]
+		changes = append(changes, parsed)
 	}
 
 	return changes
 }
 
 // FormatChanges formats changes to the CHANGELOG.md file format.
-func FormatChanges(previous string, version *semver.Version, changes []Change, date time.Time) string {
-	// Create the new entry
-	var builder strings.Builder
+func FormatChanges(previous string, version, previousVersion *semver.Version, changes []Change, date time.Time) string {
+	data := NewChangelogData(detectChangelogRepository(), version, previousVersion, changes, date)
 
-	if !date.IsZero() {
-		builder.WriteString(fmt.Sprintf("### %s / %s\n\n", date.Format("2006-01-02"), version.String()))
-	}
+	// Create the new entry and append the existing Changelog
+	return strings.TrimSpace(RenderChangelogEntry(data)) + "\n\n" + previous
+}
 
-	for _, change := range changes {
-		// Filter some commits
-		if filterCommit(change) {
-			continue
-		}
+// FormatReleaseChanges formats changes for a release.
+func FormatReleaseChanges(repository *Repository, version, previousVersion *semver.Version, changes []Change) string {
+	return RenderReleaseBody(NewChangelogData(repository, version, previousVersion, changes, GetVersionDate(version)))
+}
+
+// ReadChangelog reads the current CHANGELOG.md file, if any.
+func ReadChangelog() string {
+	cwd, _ := os.Getwd()
 
-		builder.WriteString(fmt.Sprintf("- %s: %s\n", change.Type, change.Message))
+	if _, err := os.Stat(filepath.Join(cwd, "CHANGELOG.md")); os.IsNotExist(err) {
+		return ""
 	}
 
-	// Append the existing Changelog
-	builder.WriteString("\n")
-	builder.Write([]byte(previous))
+	rawChangelog, err := ioutil.ReadFile(filepath.Join(cwd, "CHANGELOG.md"))
 
-	return builder.String()
-}
+	if err != nil {
+		Fatal("Cannot read file {errorPrimary}CHANGELOG.md{-}: {errorPrimary}%s{-}", err.Error())
+	}
 
-// FormatReleaseChanges formats changes for a GitHub release.
-func FormatReleaseChanges(repository string, changes []Change) string {
-	// Create the new entry
-	var builder strings.Builder
+	return string(rawChangelog)
+}
 
-	for _, change := range changes {
-		// Filter some commits
-		if filterCommit(change) {
-			continue
-		}
+// WriteChangelog writes the CHANGELOG.md file.
+func WriteChangelog(changelog string) {
+	cwd, _ := os.Getwd()
+	err := ioutil.WriteFile(filepath.Join(cwd, "CHANGELOG.md"), []byte(changelog), 0644)
 
-		builder.WriteString(
-			fmt.Sprintf(
-				"- %s: %s ([%s](https://github.com/%s/commit/%s))\n",
-				change.Type, change.Message, change.Hash, repository, change.Hash,
-			),
-		)
+	if err != nil {
+		Fatal("Cannot update file {errorPrimary}CHANGELOG.md{-}: {errorPrimary}%s{-}", err.Error())
 	}
-
-	return builder.String()
 }
 
 // SaveChanges persist changes from GIT to the CHANGELOG.md file.
 func SaveChanges(newVersion, currentVersion *semver.Version, changes []Change, dryRun bool) {
-	cwd, _ := os.Getwd()
-	changelog := ""
-	var err error
+	changelog := ReadChangelog()
 
-	if _, err := os.Stat(filepath.Join(cwd, "CHANGELOG.md")); !os.IsNotExist(err) {
-		rawChangelog, err := ioutil.ReadFile(filepath.Join(cwd, "CHANGELOG.md"))
-
-		if err != nil {
-			Fatal("Cannot read file {errorPrimary}CHANGELOG.md{-}: {errorPrimary}%s{-}", err.Error())
-		}
-
-		changelog = string(rawChangelog)
+	if ParseChangelog(changelog).Find(FormatVersion(newVersion)) != nil {
+		Fatal("The version {errorPrimary}%s{-} is already present in the CHANGELOG.md file.", FormatVersion(newVersion))
 	}
 
 	if len(changes) == 0 {
-		changes = ListChanges(currentVersion.String(), "")
+		changes = ListChanges(FormatVersion(currentVersion), "")
 	}
 
 	if NotifyExecution(dryRun, "Will append", "Appending", " {primary}%d{-} entries to the CHANGELOG.md file ...", len(changes)) {
-		newChangelog := FormatChanges(changelog, newVersion, changes, time.Now())
-
-		// Save the new file
-		err = ioutil.WriteFile(filepath.Join(cwd, "CHANGELOG.md"), []byte(newChangelog), 0644)
-
-		if err != nil {
-			Fatal("Cannot update file {errorPrimary}CHANGELOG.md{-}: {errorPrimary}%s{-}", err.Error())
+		if IsKeepAChangelog() {
+			WriteChangelog(FormatKeepAChangelog(changelog, newVersion, changes, time.Now()))
+		} else {
+			WriteChangelog(FormatChanges(changelog, newVersion, currentVersion, changes, time.Now()))
 		}
 	}
 
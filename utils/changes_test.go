@@ -0,0 +1,128 @@
+/*
+ * This file is part of impacca. Copyright (C) 2013 and above Shogun <shogun@cowtech.it>.
+ * Licensed under the MIT license, which can be found at https://choosealicense.com/licenses/mit.
+ */
+
+package utils
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/semver"
+	"github.com/ShogunPanda/impacca/configuration"
+)
+
+// parseTestChanges parses commit messages, using their position as hash.
+func parseTestChanges(messages ...string) []Change {
+	changes := make([]Change, 0, len(messages))
+
+	for i, message := range messages {
+		changes = append(changes, ParseCommitMessage(string(rune('a'+i)), message))
+	}
+
+	return changes
+}
+
+var testGitHubRepository = &Repository{Provider: &githubReleaseProvider{}, BaseURL: "https://github.com", Path: "owner/repo"}
+
+// useTestChangelogRepository sets the repository used in changelogs, avoiding its detection from GIT remotes.
+func useTestChangelogRepository(repository *Repository) func() {
+	changelogRepository, changelogRepositoryDetected = repository, true
+	return func() { changelogRepository, changelogRepositoryDetected = nil, false }
+}
+
+func TestGroupChanges(t *testing.T) {
+	previous := configuration.Current.Changelog
+	defer func() { configuration.Current.Changelog = previous }()
+
+	messages := []string{
+		"feat(lexer): Objects", "fix: Crash", "Fixed typo.", "docs: Readme", "feat!: New API", "ci: Build", "Updated CHANGELOG.md.", "1.2.3",
+	}
+
+	cases := []struct {
+		grouped  bool
+		sections []configuration.ChangelogSection
+		hidden   []string
+		expected map[string][]string
+	}{
+		{
+			false, previous.Sections, []string{},
+			map[string][]string{"": {"a", "b", "c", "d", "e", "f"}},
+		},
+		{
+			false, previous.Sections, []string{"docs", "ci"},
+			map[string][]string{"": {"a", "b", "c", "e"}},
+		},
+		{
+			true, previous.Sections, []string{"ci"},
+			map[string][]string{"Breaking Changes": {"e"}, "Features": {"a"}, "Bug Fixes": {"b"}, "Other Changes": {"c", "d"}},
+		},
+		{
+			true, []configuration.ChangelogSection{{Title: "Everything", Types: []string{"*"}}, {Title: "Documentation", Types: []string{"docs"}}},
+			[]string{"feat"},
+			map[string][]string{"Everything": {"b", "c", "e", "f"}, "Documentation": {"d"}},
+		},
+		{
+			true, []configuration.ChangelogSection{{Title: "Features", Types: []string{"feat"}}}, []string{},
+			map[string][]string{"Features": {"a", "e"}},
+		},
+		{
+			true, []configuration.ChangelogSection{}, []string{},
+			map[string][]string{"": {"a", "b", "c", "d", "e", "f"}},
+		},
+	}
+
+	for i, tc := range cases {
+		configuration.Current.Changelog.Grouped = tc.grouped
+		configuration.Current.Changelog.Sections = tc.sections
+		configuration.Current.Changelog.HiddenTypes = tc.hidden
+
+		actual := make(map[string][]string)
+
+		for _, group := range GroupChanges(parseTestChanges(messages...)) {
+			hashes := make([]string, 0, len(group.Changes))
+
+			for _, change := range group.Changes {
+				hashes = append(hashes, change.Hash)
+			}
+
+			actual[group.Title] = hashes
+		}
+
+		if !reflect.DeepEqual(actual, tc.expected) {
+			t.Errorf("case %d: expected %v, got %v", i, tc.expected, actual)
+		}
+	}
+}
+
+func TestFormatChangesGrouped(t *testing.T) {
+	previous := configuration.Current.Changelog
+	defer func() { configuration.Current.Changelog = previous }()
+
+	defer useTestChangelogRepository(nil)()
+
+	configuration.Current.Changelog.Grouped = true
+	changes := parseTestChanges("feat(lexer): Objects", "fix: Crash", "Fixed typo.")
+	date := time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	expected := "### 2019-01-02 / 1.3.0\n\n" +
+		"#### Features\n\n- lexer: Objects\n\n" +
+		"#### Bug Fixes\n\n- Crash\n\n" +
+		"#### Other Changes\n\n- Fixed typo.\n" +
+		"\n### 2018-12-01 / 1.2.3\n"
+
+	if actual := FormatChanges("### 2018-12-01 / 1.2.3\n", semver.MustParse("1.3.0"), semver.MustParse("1.2.3"), changes, date); actual != expected {
+		t.Errorf("unexpected changelog:\n%s", actual)
+	}
+
+	configuration.Current.Changelog.Grouped = false
+	expected = "- feat(lexer): Objects ([a](https://github.com/owner/repo/commit/a))\n" +
+		"- fix: Crash ([b](https://github.com/owner/repo/commit/b))\n" +
+		"- Fixed typo. ([c](https://github.com/owner/repo/commit/c))\n"
+
+	if actual := RenderReleaseBody(NewChangelogData(testGitHubRepository, semver.MustParse("1.3.0"), semver.MustParse("1.2.3"), changes, date)); actual != expected {
+		t.Errorf("unexpected release body:\n%s", actual)
+	}
+}
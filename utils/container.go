@@ -0,0 +1,47 @@
+/*
+ * This file is part of impacca. Copyright (C) 2013 and above Shogun <shogun@cowtech.it>.
+ * Licensed under the MIT license, which can be found at https://choosealicense.com/licenses/mit.
+ */
+
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/ShogunPanda/impacca/configuration"
+)
+
+// ContainerImageTag returns the container image tag of a version. Image tags cannot contain the plus sign of build metadata.
+func ContainerImageTag(version *semver.Version) string {
+	return configuration.Current.Container.Image + ":" + strings.Replace(FormatVersion(version), "+", "_", -1)
+}
+
+// BuildContainerImage builds, tags and pushes the container image of a version, if an image is configured.
+func BuildContainerImage(version *semver.Version, dryRun bool) {
+	settings := configuration.Current.Container
+
+	if settings.Image == "" {
+		return
+	}
+
+	cwd, _ := os.Getwd()
+	dockerfile := filepath.Join(cwd, settings.Dockerfile)
+	image := ContainerImageTag(version)
+
+	if _, err := os.Stat(dockerfile); err != nil {
+		Fatal("Cannot find the container file {errorPrimary}%s{-}.", settings.Dockerfile)
+	}
+
+	if NotifyExecution(dryRun, "Will execute", "Executing", ": {primary}%s build --tag %s --file %s .{-} ...", settings.Builder, image, settings.Dockerfile) {
+		result := Execute(true, settings.Builder, "build", "--tag", image, "--file", dockerfile, cwd)
+		result.Verify(settings.Builder, "Cannot build the container image")
+	}
+
+	if settings.Push && NotifyExecution(dryRun, "Will execute", "Executing", ": {primary}%s push %s{-} ...", settings.Builder, image) {
+		result := Execute(true, settings.Builder, "push", image)
+		result.Verify(settings.Builder, "Cannot push the container image")
+	}
+}
@@ -0,0 +1,169 @@
+/*
+ * This file is part of impacca. Copyright (C) 2013 and above Shogun <shogun@cowtech.it>.
+ * Licensed under the MIT license, which can be found at https://choosealicense.com/licenses/mit.
+ */
+
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// See https://www.conventionalcommits.org/en/v1.0.0/#specification
+var conventionalHeaderMatcher = regexp.MustCompile("^([A-Za-z][A-Za-z0-9_-]*)(?:\\(([^()\\r\\n]*)\\))?(!)?: (\\S.*)$")
+var footerMatcher = regexp.MustCompile("^(BREAKING[ -]CHANGE|[A-Za-z][A-Za-z0-9-]*)(?:: | #)(.*)$")
+var issueMatcher = regexp.MustCompile("(?:^|[^A-Za-z0-9_/&])((?:[A-Za-z0-9_.-]+/[A-Za-z0-9_.-]+)?#\\d+)\\b")
+
+// Footer represents a git commit trailer, like "BREAKING CHANGE: ..." or "Refs #123"
+type Footer struct {
+	Token string
+	Value string
+}
+
+// Change represents a git commit, parsed according to the Conventional Commits 1.0 specification.
+// Commits not following the specification have an empty Type and the whole subject as Message.
+type Change struct {
+	Hash        string
+	Author      string
+	AuthorEmail string
+	Type        string
+	Scope       string
+	Breaking    bool
+	Message     string
+	Body        string
+	Footers     []Footer
+	Issues      []string
+}
+
+// Prefix returns the change type, scope and breaking marker in the Conventional Commits format.
+func (c Change) Prefix() string {
+	if c.Type == "" {
+		return ""
+	}
+
+	prefix := c.Type
+
+	if c.Scope != "" {
+		prefix += fmt.Sprintf("(%s)", c.Scope)
+	}
+
+	if c.Breaking {
+		prefix += "!"
+	}
+
+	return prefix
+}
+
+// Summary returns the change header in the Conventional Commits format.
+func (c Change) Summary() string {
+	if c.Type == "" {
+		return c.Message
+	}
+
+	return fmt.Sprintf("%s: %s", c.Prefix(), c.Message)
+}
+
+// ParseCommitMessage parses a raw commit message (subject, body and trailers).
+func ParseCommitMessage(hash, message string) Change {
+	message = strings.TrimSpace(strings.Replace(message, "\r\n", "\n", -1))
+	tokens := strings.SplitN(message, "\n", 2)
+	subject := strings.TrimSpace(tokens[0])
+
+	body := ""
+	if len(tokens) > 1 {
+		body = tokens[1]
+	}
+
+	body, footers := parseFooters(body)
+	change := Change{Hash: strings.ToLower(hash), Message: subject, Body: body, Footers: footers, Breaking: hasBreakingFooter(footers)}
+
+	if matches := conventionalHeaderMatcher.FindStringSubmatch(subject); matches != nil {
+		change.Type = strings.ToLower(matches[1])
+		change.Scope = strings.TrimSpace(matches[2])
+		change.Breaking = change.Breaking || matches[3] == "!"
+		change.Message = strings.TrimSpace(matches[4])
+	}
+
+	change.Issues = parseIssues(change)
+	return change
+}
+
+// parseFooters splits the trailing footers paragraph from a commit body.
+func parseFooters(body string) (string, []Footer) {
+	body = strings.TrimSpace(body)
+	footers := make([]Footer, 0)
+
+	if body == "" {
+		return body, footers
+	}
+
+	// Footers can only appear in the last paragraph
+	paragraphStart := strings.LastIndex(body, "\n\n")
+	lastParagraph := body[paragraphStart+1:]
+
+	if paragraphStart == -1 {
+		lastParagraph = body
+	}
+
+	lines := strings.Split(strings.TrimSpace(lastParagraph), "\n")
+
+	if !footerMatcher.MatchString(lines[0]) {
+		return body, footers
+	}
+
+	for _, line := range lines {
+		if matches := footerMatcher.FindStringSubmatch(line); matches != nil {
+			footers = append(footers, Footer{Token: matches[1], Value: strings.TrimSpace(matches[2])})
+		} else {
+			// Continuation of the previous footer value
+			last := &footers[len(footers)-1]
+			last.Value = strings.TrimSpace(last.Value + "\n" + strings.TrimSpace(line))
+		}
+	}
+
+	if paragraphStart == -1 {
+		return "", footers
+	}
+
+	return strings.TrimSpace(body[:paragraphStart]), footers
+}
+
+// hasBreakingFooter checks if a list of footers contains a breaking change notice.
+func hasBreakingFooter(footers []Footer) bool {
+	for _, footer := range footers {
+		if footer.Token == "BREAKING CHANGE" || footer.Token == "BREAKING-CHANGE" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseIssues extracts all issues references (like #123 or owner/repo#123) from a change.
+func parseIssues(change Change) []string {
+	issues := make([]string, 0)
+	seen := make(map[string]bool)
+	sources := []string{change.Message, change.Body}
+
+	for _, footer := range change.Footers {
+		// Footers like "Refs #123" lose the hash sign when parsed
+		if strings.IndexFunc(footer.Value, func(r rune) bool { return r < '0' || r > '9' }) == -1 {
+			sources = append(sources, "#"+footer.Value)
+		} else {
+			sources = append(sources, footer.Value)
+		}
+	}
+
+	for _, source := range sources {
+		for _, matches := range issueMatcher.FindAllStringSubmatch(source, -1) {
+			if !seen[matches[1]] {
+				seen[matches[1]] = true
+				issues = append(issues, matches[1])
+			}
+		}
+	}
+
+	return issues
+}
@@ -0,0 +1,78 @@
+/*
+ * This file is part of impacca. Copyright (C) 2013 and above Shogun <shogun@cowtech.it>.
+ * Licensed under the MIT license, which can be found at https://choosealicense.com/licenses/mit.
+ */
+
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCommitMessage(t *testing.T) {
+	cases := []struct {
+		message  string
+		expected Change
+	}{
+		{
+			"Fixed typo.",
+			Change{Message: "Fixed typo.", Footers: []Footer{}, Issues: []string{}},
+		},
+		{
+			"feat(parser): Add arrays support",
+			Change{Type: "feat", Scope: "parser", Message: "Add arrays support", Footers: []Footer{}, Issues: []string{}},
+		},
+		{
+			"Fix!: drop Node 8 (#12)",
+			Change{Type: "fix", Breaking: true, Message: "drop Node 8 (#12)", Footers: []Footer{}, Issues: []string{"#12"}},
+		},
+		{
+			"refactor: Rewrite the lexer\r\n\r\nThe lexer is now faster.\r\n\r\nBREAKING CHANGE: tokens are now objects\r\n  with a type.\r\nRefs #34\r\nCloses: owner/repo#56",
+			Change{
+				Type: "refactor", Breaking: true, Message: "Rewrite the lexer", Body: "The lexer is now faster.",
+				Footers: []Footer{
+					{Token: "BREAKING CHANGE", Value: "tokens are now objects\nwith a type."},
+					{Token: "Refs", Value: "34"},
+					{Token: "Closes", Value: "owner/repo#56"},
+				},
+				Issues: []string{"#34", "owner/repo#56"},
+			},
+		},
+		{
+			"docs: Explain footers\n\nThis is not a footer: it is in the body.\n\nAnd neither is this.",
+			Change{
+				Type: "docs", Message: "Explain footers", Body: "This is not a footer: it is in the body.\n\nAnd neither is this.",
+				Footers: []Footer{}, Issues: []string{},
+			},
+		},
+		{
+			"chore(): Not a scope",
+			Change{Type: "chore", Message: "Not a scope", Footers: []Footer{}, Issues: []string{}},
+		},
+		{
+			"feat:missing space",
+			Change{Message: "feat:missing space", Footers: []Footer{}, Issues: []string{}},
+		},
+	}
+
+	for _, tc := range cases {
+		if actual := ParseCommitMessage("", tc.message); !reflect.DeepEqual(actual, tc.expected) {
+			t.Errorf("%q:\nexpected %+v\ngot      %+v", tc.message, tc.expected, actual)
+		}
+	}
+}
+
+func TestChangeSummary(t *testing.T) {
+	cases := map[string]string{
+		"feat(parser)!: Add arrays support": "feat(parser)!: Add arrays support",
+		"FIX: Typo":                         "fix: Typo",
+		"Fixed typo.":                       "Fixed typo.",
+	}
+
+	for message, expected := range cases {
+		if actual := ParseCommitMessage("ABCDEF", message).Summary(); actual != expected {
+			t.Errorf("%q: expected %q, got %q", message, expected, actual)
+		}
+	}
+}
@@ -6,48 +6,120 @@
 package utils
 
 import (
-	"encoding/json"
-	"io/ioutil"
 	"os"
-	"path/filepath"
-	"regexp"
+	"strings"
+
+	"github.com/Masterminds/semver"
 )
 
-type npmPackageJSON struct {
-	Private bool `json:"private"`
+// PublishOptions contains the options of the publishing of a package
+type PublishOptions struct {
+	Private bool
+	DryRun  bool
 }
 
-var versionMatcher = regexp.MustCompile("^(v(?:-?))")
+// PackageManager updates the version of a kind of package and publishes it
+type PackageManager interface {
+	// Name returns the name used to force the package manager, like npm.
+	Name() string
+	// Detect checks if a folder contains a package handled by the package manager.
+	Detect(folder string) bool
+	// CurrentVersion returns the version declared in the package files, if any.
+	CurrentVersion() string
+	// SetVersion updates the version in the package files, without committing.
+	SetVersion(newVersion, currentVersion *semver.Version, dryRun bool)
+	// Files returns the files changed by the last SetVersion, which must be committed.
+	Files() []string
+	// Publish publishes a version which has already been committed and tagged.
+	Publish(version *semver.Version, options PublishOptions)
+}
 
-const (
-	// PlainPackageManager releases using Git
-	PlainPackageManager int = iota
-	// NpmPackageManager releases using npm
-	NpmPackageManager
-	// GemPackageManager release using "rake release" task
-	GemPackageManager
-)
+// DevelopmentPackageManager is implemented by package managers which switch to a development version after a release
+type DevelopmentPackageManager interface {
+	PrepareDevelopmentVersion(releasedVersion *semver.Version, dryRun bool)
+}
 
-// DetectPackageManager detects which kind of release we have to use
-func DetectPackageManager() int {
-	cwd, _ := os.Getwd()
+// PackageManagers contains the available package managers, in detection order.
+// The last one is used when no other package manager is detected.
+var PackageManagers = []PackageManager{
+	&npmPackageManager{},
+	&gemPackageManager{},
+	&goPackageManager{},
+	&cargoPackageManager{},
+	&pythonPackageManager{},
+	&jvmPackageManager{maven: true},
+	&jvmPackageManager{maven: false},
+	&helmPackageManager{},
+	&plainPackageManager{},
+}
+
+// ForcedPackageManager is the name of the package manager to use instead of detecting it
+var ForcedPackageManager string
+
+// changedFiles tracks the files changed by a package manager
+type changedFiles struct {
+	files []string
+}
+
+func (c *changedFiles) Files() []string {
+	return c.files
+}
+
+func (c *changedFiles) resetFiles() {
+	c.files = make([]string, 0)
+}
+
+func (c *changedFiles) trackFile(file string) {
+	c.files = append(c.files, file)
+}
 
-	if _, err := os.Stat(filepath.Join(cwd, "package.json")); !os.IsNotExist(err) {
-		// Parse the package.json
-		var parsed npmPackageJSON
-		rawConfiguration, err := ioutil.ReadFile(filepath.Join(cwd, "package.json"))
+// PackageManagerNames returns the names of all package managers.
+func PackageManagerNames() []string {
+	names := make([]string, len(PackageManagers))
 
-		if err == nil {
-			err = json.Unmarshal(rawConfiguration, &parsed)
+	for i, manager := range PackageManagers {
+		names[i] = manager.Name()
+	}
+
+	return names
+}
+
+// FindPackageManager finds a package manager by name.
+func FindPackageManager(name string) PackageManager {
+	for _, manager := range PackageManagers {
+		if strings.EqualFold(manager.Name(), name) {
+			return manager
+		}
+	}
+
+	return nil
+}
+
+// DetectPackageManager detects which kind of release we have to use
+func DetectPackageManager() PackageManager {
+	if ForcedPackageManager != "" {
+		manager := FindPackageManager(ForcedPackageManager)
+
+		if manager == nil {
+			Fatal(
+				"Unsupported package manager {errorPrimary}%s{-}. Supported ones are: {errorPrimary}%s{-}.",
+				ForcedPackageManager, strings.Join(PackageManagerNames(), ", "),
+			)
 		}
 
-		// If the package.json file is marked as private, treat as PlainRelease
-		if err != nil || !parsed.Private {
-			return NpmPackageManager
+		return manager
+	}
+
+	cwd, _ := os.Getwd()
+	return detectPackageManager(cwd)
+}
+
+func detectPackageManager(cwd string) PackageManager {
+	for _, manager := range PackageManagers[:len(PackageManagers)-1] {
+		if manager.Detect(cwd) {
+			return manager
 		}
-	} else if specs, err := filepath.Glob(filepath.Join(cwd, "*.gemspec")); err == nil && len(specs) > 0 {
-		return GemPackageManager
 	}
 
-	return PlainPackageManager
+	return PackageManagers[len(PackageManagers)-1]
 }
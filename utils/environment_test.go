@@ -0,0 +1,53 @@
+/*
+ * This file is part of impacca. Copyright (C) 2013 and above Shogun <shogun@cowtech.it>.
+ * Licensed under the MIT license, which can be found at https://choosealicense.com/licenses/mit.
+ */
+
+package utils
+
+import (
+	"testing"
+)
+
+func TestDetectPackageManager(t *testing.T) {
+	cases := []struct {
+		name     string
+		files    map[string]string
+		forced   string
+		expected string
+	}{
+		{"npm", map[string]string{"package.json": "{}"}, "", "npm"},
+		{"private npm", map[string]string{"package.json": "{\"private\": true}"}, "", "plain"},
+		{"gem", map[string]string{"app.gemspec": ""}, "", "gem"},
+		{"go", map[string]string{"go.mod": "module app\n", "pom.xml": "<project></project>\n"}, "", "go"},
+		{"cargo", map[string]string{"Cargo.toml": ""}, "", "cargo"},
+		{"python", map[string]string{"pyproject.toml": ""}, "", "python"},
+		{"maven", map[string]string{"pom.xml": "<project></project>\n", "build.gradle": ""}, "", "maven"},
+		{"gradle", map[string]string{"build.gradle.kts": ""}, "", "gradle"},
+		{"gradle properties", map[string]string{"gradle.properties": ""}, "", "gradle"},
+		{"helm", map[string]string{"Chart.yaml": ""}, "", "helm"},
+		{"plain", map[string]string{"README.md": ""}, "", "plain"},
+		{"forced", map[string]string{"package.json": "{}"}, "Cargo", "cargo"},
+		{"forced plain", map[string]string{"pom.xml": "<project></project>\n"}, "plain", "plain"},
+	}
+
+	defer func() { ForcedPackageManager = "" }()
+
+	for _, tc := range cases {
+		done := useTestFolder(t, tc.files)
+		ForcedPackageManager = tc.forced
+
+		if actual := DetectPackageManager().Name(); actual != tc.expected {
+			t.Errorf("%s: expected %s, got %s", tc.name, tc.expected, actual)
+		}
+
+		done()
+	}
+}
+
+func TestDetectUnsupportedPackageManager(t *testing.T) {
+	expectFatal(t, "Unsupported package manager", func() {
+		ForcedPackageManager = "pip"
+		DetectPackageManager()
+	})
+}
@@ -81,9 +81,15 @@ func Execute(showOutput bool, cmd string, args ...string) (result ExecutionResul
 
 	// Execute the command
 	Debug("Executing: %s %s", cmd, strings.Join(args, " "))
-	result.Error = gitCmd.Run()
+
+	// All output must be read before waiting for the command, otherwise it might get lost
+	result.Error = gitCmd.Start()
 	wg.Wait()
 
+	if result.Error == nil {
+		result.Error = gitCmd.Wait()
+	}
+
 	// The command exited with errors, copy the exit code
 	if result.Error != nil {
 		if exitError, casted := result.Error.(*exec.ExitError); casted {
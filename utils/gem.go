@@ -0,0 +1,102 @@
+/*
+ * This file is part of impacca. Copyright (C) 2013 and above Shogun <shogun@cowtech.it>.
+ * Licensed under the MIT license, which can be found at https://choosealicense.com/licenses/mit.
+ */
+
+package utils
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/Masterminds/semver"
+)
+
+var gemVersionMatchers = map[string]*regexp.Regexp{
+	"MAJOR": regexp.MustCompile("(?m)^(?:(\\s*MAJOR)\\s*=\\s*(\\d+))$"),
+	"MINOR": regexp.MustCompile("(?m)^(?:(\\s*MINOR)\\s*=\\s*(\\d+))$"),
+	"PATCH": regexp.MustCompile("(?m)^(?:(\\s*PATCH)\\s*=\\s*(\\d+))$"),
+}
+
+// gemPackageManager release using "rake release" task
+type gemPackageManager struct {
+	changedFiles
+}
+
+// gemVersionFile returns the version file of the gem.
+func gemVersionFile() string {
+	cwd, _ := os.Getwd()
+	files, _ := filepath.Glob(filepath.Join(cwd, "*/*/version.rb"))
+
+	if len(files) != 1 {
+		Fatal("Found no or more than one possible gem version files.")
+	}
+
+	return files[0]
+}
+
+func (m *gemPackageManager) Name() string {
+	return "gem"
+}
+
+func (m *gemPackageManager) Detect(folder string) bool {
+	specs, err := filepath.Glob(filepath.Join(folder, "*.gemspec"))
+	return err == nil && len(specs) > 0
+}
+
+func (m *gemPackageManager) CurrentVersion() string {
+	contents := readTextFile(gemVersionFile())
+	parts := make([]interface{}, 0, 3)
+
+	for _, part := range []string{"MAJOR", "MINOR", "PATCH"} {
+		matches := gemVersionMatchers[part].FindStringSubmatch(contents)
+
+		if matches == nil {
+			return ""
+		}
+
+		parts = append(parts, matches[2])
+	}
+
+	return fmt.Sprintf("%s.%s.%s", parts...)
+}
+
+// SetVersion updates the current version by manipulating the version file.
+func (m *gemPackageManager) SetVersion(newVersion, currentVersion *semver.Version, dryRun bool) {
+	// Open the version file
+	versionFile := gemVersionFile()
+	rawVersionContents, err := ioutil.ReadFile(versionFile)
+
+	if err != nil {
+		Fatal("Cannot read gem version file {errorPrimary}%s{-}: {errorPrimary}%s{-}", versionFile, err.Error())
+	}
+
+	m.resetFiles()
+	m.trackFile(versionFile)
+
+	if !dryRun {
+		versionContents := string(rawVersionContents)
+
+		// Replace contents
+		versionContents = gemVersionMatchers["MAJOR"].ReplaceAllString(versionContents, fmt.Sprintf("$1 = %d", newVersion.Major()))
+		versionContents = gemVersionMatchers["MINOR"].ReplaceAllString(versionContents, fmt.Sprintf("$1 = %d", newVersion.Minor()))
+		versionContents = gemVersionMatchers["PATCH"].ReplaceAllString(versionContents, fmt.Sprintf("$1 = %d", newVersion.Patch()))
+
+		err := ioutil.WriteFile(versionFile, []byte(versionContents), 0644)
+
+		if err != nil {
+			Fatal("Cannot update gem version file {errorPrimary}%s{-}: {errorPrimary}%s{-}", versionFile, err.Error())
+		}
+	}
+}
+
+// Publish builds the gem and pushes it using the "rake release" task.
+func (m *gemPackageManager) Publish(version *semver.Version, options PublishOptions) {
+	if NotifyExecution(options.DryRun, "Will execute", "Executing", ": {primary}rake release{-} ...") {
+		result := Execute(true, "rake", "release")
+		result.Verify("rake", "Cannot publish the gem")
+	}
+}
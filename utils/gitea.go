@@ -0,0 +1,170 @@
+/*
+ * This file is part of impacca. Copyright (C) 2013 and above Shogun <shogun@cowtech.it>.
+ * Licensed under the MIT license, which can be found at https://choosealicense.com/licenses/mit.
+ */
+
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/url"
+	"path/filepath"
+
+	"github.com/ShogunPanda/impacca/configuration"
+	"gopkg.in/h2non/gentleman.v2"
+	"gopkg.in/h2non/gentleman.v2/plugins/body"
+)
+
+// giteaReleaseProvider manages Gitea and Forgejo releases, whose API is similar to the GitHub one
+type giteaReleaseProvider struct{}
+
+// giteaRequest prepares a Gitea API request on a repository. The path is relative to the repository API URL.
+func giteaRequest(method string, repository *Repository, path, token string) *gentleman.Request {
+	cli := gentleman.New()
+
+	req := cli.Request()
+	req.Method(method)
+	req.URL(fmt.Sprintf("%s/api/v1/repos/%s%s", repository.BaseURL, repository.Path, path))
+
+	if token != "" {
+		req.SetHeader("Authorization", fmt.Sprintf("token %s", token))
+	}
+
+	return req
+}
+
+// GiteaAPICall performs a Gitea API call on a repository. The path is relative to the repository API URL.
+func GiteaAPICall(message, method string, repository *Repository, path, token string, data map[string]interface{}, allowNotFound bool) *gentleman.Response {
+	req := giteaRequest(method, repository, path, token)
+
+	if method != "GET" {
+		req.Use(body.JSON(data))
+	}
+
+	return sendReleaseRequest(message, req, allowNotFound)
+}
+
+func (p *giteaReleaseProvider) Name() string {
+	return giteaForge
+}
+
+func (p *giteaReleaseProvider) Title() string {
+	return "Gitea"
+}
+
+func (p *giteaReleaseProvider) Detect(host, baseURL, path string) *Repository {
+	if giteaURL, isGitea := matchHost(configuration.Current.Gitea.Hosts, host, baseURL); isGitea {
+		return &Repository{Provider: p, BaseURL: giteaURL, Path: path}
+	}
+
+	return nil
+}
+
+// List returns the releases of a repository. Pages have at most 50 releases, which is the default limit of Gitea.
+func (p *giteaReleaseProvider) List(repository *Repository, token string) []Release {
+	releases := make([]Release, 0)
+
+	for page := 1; ; page++ {
+		res := GiteaAPICall("get Gitea releases", "GET", repository, fmt.Sprintf("/releases?limit=50&page=%d", page), token, nil, false)
+		releases = append(releases, decodeGithubReleases("get Gitea releases", res)...)
+
+		if !hasNextPage(res) {
+			return releases
+		}
+	}
+}
+
+func (p *giteaReleaseProvider) Get(repository *Repository, token, tag string) *Release {
+	res := GiteaAPICall("find a Gitea release", "GET", repository, "/releases/tags/"+url.PathEscape(tag), token, nil, true)
+
+	if res.StatusCode == 404 {
+		return nil
+	}
+
+	return decodeGithubRelease("find a Gitea release", res)
+}
+
+func (p *giteaReleaseProvider) Create(repository *Repository, token string, release *Release) *Release {
+	res := GiteaAPICall("create a Gitea release", "POST", repository, "/releases", token, githubReleaseData(release), false)
+	return decodeGithubRelease("create a Gitea release", res)
+}
+
+func (p *giteaReleaseProvider) Update(repository *Repository, token string, release *Release) *Release {
+	res := GiteaAPICall(
+		"update a Gitea release", "PATCH", repository, fmt.Sprintf("/releases/%d", release.ID), token, githubReleaseData(release), false,
+	)
+
+	return decodeGithubRelease("update a Gitea release", res)
+}
+
+func (p *giteaReleaseProvider) Delete(repository *Repository, token string, release *Release) {
+	GiteaAPICall("delete a Gitea release", "DELETE", repository, fmt.Sprintf("/releases/%d", release.ID), token, nil, false)
+}
+
+// ListAssets returns the assets of a release. Pages have at most 50 assets, which is the default limit of Gitea.
+func (p *giteaReleaseProvider) ListAssets(repository *Repository, token string, release *Release) []ReleaseAsset {
+	assets := make([]ReleaseAsset, 0)
+
+	for page := 1; ; page++ {
+		res := GiteaAPICall(
+			"get Gitea release assets", "GET", repository, fmt.Sprintf("/releases/%d/assets?limit=50&page=%d", release.ID, page), token, nil, false,
+		)
+
+		assets = append(assets, decodeGithubReleaseAssets("get Gitea release assets", res)...)
+
+		if !hasNextPage(res) {
+			return assets
+		}
+	}
+}
+
+// uploadGiteaAsset uploads a file to a release with a name, as a multipart form which is supported by all Gitea and Forgejo versions.
+func uploadGiteaAsset(repository *Repository, token string, release *Release, file, name string) ReleaseAsset {
+	source, size := openAssetFile(file)
+	defer source.Close()
+
+	// The file is streamed between the form part headers and the closing boundary
+	var form bytes.Buffer
+	writer := multipart.NewWriter(&form)
+
+	if _, err := writer.CreateFormFile("attachment", name); err != nil {
+		Fatal("Cannot prepare the upload of file {errorPrimary}%s{-}: {errorPrimary}%s{-}", file, err.Error())
+	}
+
+	header := append([]byte{}, form.Bytes()...)
+	form.Reset()
+	writer.Close()
+	footer := form.Bytes()
+
+	req := giteaRequest("POST", repository, fmt.Sprintf("/releases/%d/assets", release.ID), token)
+	req.SetQuery("name", name)
+	req.SetHeader("Content-Type", writer.FormDataContentType())
+	useStreamBody(req, io.MultiReader(bytes.NewReader(header), source, bytes.NewReader(footer)), int64(len(header))+size+int64(len(footer)))
+
+	res := sendReleaseRequest("upload a Gitea release asset", req, false)
+	return decodeGithubReleaseAsset("upload a Gitea release asset", res)
+}
+
+func (p *giteaReleaseProvider) UploadAsset(repository *Repository, token string, release *Release, file string) {
+	uploadGiteaAsset(repository, token, release, file, filepath.Base(file))
+}
+
+// ReplaceAsset uploads the file with a temporary name, then deletes the asset and renames the uploaded one.
+func (p *giteaReleaseProvider) ReplaceAsset(repository *Repository, token string, release *Release, asset ReleaseAsset, file string) {
+	uploaded := uploadGiteaAsset(repository, token, release, file, temporaryAssetName(asset.Name))
+	p.DeleteAsset(repository, token, release, asset)
+
+	GiteaAPICall(
+		"rename a Gitea release asset", "PATCH", repository, fmt.Sprintf("/releases/%d/assets/%d", release.ID, uploaded.ID),
+		token, map[string]interface{}{"name": asset.Name}, false,
+	)
+}
+
+func (p *giteaReleaseProvider) DeleteAsset(repository *Repository, token string, release *Release, asset ReleaseAsset) {
+	GiteaAPICall(
+		"delete a Gitea release asset", "DELETE", repository, fmt.Sprintf("/releases/%d/assets/%d", release.ID, asset.ID), token, nil, false,
+	)
+}
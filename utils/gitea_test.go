@@ -0,0 +1,209 @@
+/*
+ * This file is part of impacca. Copyright (C) 2013 and above Shogun <shogun@cowtech.it>.
+ * Licensed under the MIT license, which can be found at https://choosealicense.com/licenses/mit.
+ */
+
+package utils
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ShogunPanda/impacca/configuration"
+)
+
+// useGiteaTestRepository returns a repository whose Gitea API is served by a handler.
+func useGiteaTestRepository(handler http.HandlerFunc) (*Repository, func()) {
+	baseURL, done := useTestAPIServer(handler)
+	return &Repository{Provider: &giteaReleaseProvider{}, BaseURL: baseURL, Path: "owner/repo"}, done
+}
+
+func TestGiteaDetect(t *testing.T) {
+	previous := configuration.Current.Gitea
+	configuration.Current.Gitea.Hosts = []string{"codeberg.org", "https://git.example.com:3000/"}
+	defer func() { configuration.Current.Gitea = previous }()
+
+	cases := []struct {
+		remote  string
+		baseURL string
+		path    string
+	}{
+		{"https://codeberg.org/owner/repo.git", "https://codeberg.org", "owner/repo"},
+		{"http://git.example.com:3000/owner/repo", "https://git.example.com:3000", "owner/repo"},
+		{"https://git.example.com/owner/repo.git", "https://git.example.com:3000", "owner/repo"},
+		{"https://github.com/owner/repo.git", "", ""},
+	}
+
+	provider := &giteaReleaseProvider{}
+
+	for _, c := range cases {
+		repository := detectTestRemote(provider, c.remote)
+
+		if c.baseURL == "" {
+			if repository != nil {
+				t.Errorf("%s: unexpected repository %+v", c.remote, repository)
+			}
+
+			continue
+		}
+
+		if repository == nil || repository.BaseURL != c.baseURL || repository.Path != c.path {
+			t.Errorf("%s: unexpected repository %+v", c.remote, repository)
+		}
+	}
+}
+
+func TestGiteaGet(t *testing.T) {
+	repository, done := useGiteaTestRepository(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/api/v1/repos/owner/repo/releases/tags/mylib%2Fv1.2.3" {
+			http.NotFound(w, r)
+			return
+		}
+
+		if r.Header.Get("Authorization") != "token token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.Write([]byte(`{"id": 42, "tag_name": "mylib/v1.2.3", "name": "1.2.3", "body": "Changes", "prerelease": false}`))
+	})
+
+	defer done()
+
+	release := repository.Provider.Get(repository, "token", "mylib/v1.2.3")
+
+	if release == nil || release.ID != 42 || FormatVersion(release.Version) != "1.2.3" || release.Body != "Changes" {
+		t.Fatalf("unexpected release: %+v", release)
+	}
+
+	if missing := repository.Provider.Get(repository, "token", "mylib/v2.0.0"); missing != nil {
+		t.Errorf("unexpected release: %+v", missing)
+	}
+}
+
+func TestGiteaListFollowsPages(t *testing.T) {
+	repository, done := useGiteaTestRepository(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/repos/owner/repo/releases" || r.URL.Query().Get("limit") != "50" {
+			http.NotFound(w, r)
+			return
+		}
+
+		switch r.URL.Query().Get("page") {
+		case "1":
+			w.Header().Set("Link", `<http://localhost/releases?page=2&limit=50>; rel="next",<http://localhost/releases?page=2&limit=50>; rel="last"`)
+			w.Write([]byte(`[{"id": 2, "tag_name": "v1.1.0", "name": "1.1.0"}]`))
+		case "2":
+			w.Header().Set("Link", `<http://localhost/releases?page=1&limit=50>; rel="prev"`)
+			w.Write([]byte(`[{"id": 1, "tag_name": "v1.0.0", "name": "1.0.0"}]`))
+		default:
+			w.Write([]byte(`[]`))
+		}
+	})
+
+	defer done()
+
+	releases := repository.Provider.List(repository, "token")
+
+	if len(releases) != 2 || releases[0].ID != 2 || releases[1].ID != 1 {
+		t.Errorf("unexpected releases: %+v", releases)
+	}
+}
+
+func TestGiteaListAssetsFollowsPages(t *testing.T) {
+	repository, done := useGiteaTestRepository(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/repos/owner/repo/releases/10/assets" || r.URL.Query().Get("limit") != "50" {
+			http.NotFound(w, r)
+			return
+		}
+
+		switch r.URL.Query().Get("page") {
+		case "1":
+			w.Header().Set("Link", `<http://localhost/releases/10/assets?page=2&limit=50>; rel="next"`)
+			w.Write([]byte(`[{"id": 2, "name": "app.bin", "size": 3}]`))
+		case "2":
+			w.Header().Set("Link", `<http://localhost/releases/10/assets?page=1&limit=50>; rel="prev"`)
+			w.Write([]byte(`[{"id": 1, "name": "app.bin.sha256", "size": 10}]`))
+		default:
+			w.Write([]byte(`[]`))
+		}
+	})
+
+	defer done()
+
+	assets := repository.Provider.ListAssets(repository, "token", &Release{ID: 10})
+
+	if len(assets) != 2 || assets[0].Name != "app.bin" || assets[1].Name != "app.bin.sha256" {
+		t.Errorf("unexpected assets: %+v", assets)
+	}
+}
+
+func TestGiteaCreateAndUpdate(t *testing.T) {
+	requests := make([]string, 0)
+	var data map[string]interface{}
+
+	repository, done := useGiteaTestRepository(func(w http.ResponseWriter, r *http.Request) {
+		contents, _ := ioutil.ReadAll(r.Body)
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		json.Unmarshal(contents, &data)
+
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id": 42, "tag_name": "v1.2.3-rc.0", "name": "1.2.3-rc.0", "body": "Changes", "prerelease": true}`))
+	})
+
+	defer done()
+
+	release := &Release{Tag: "v1.2.3-rc.0", Name: "1.2.3-rc.0", Body: "Changes", Prerelease: true}
+	created := repository.Provider.Create(repository, "token", release)
+
+	if created.ID != 42 || !created.Prerelease || data["tag_name"] != "v1.2.3-rc.0" || data["prerelease"] != true {
+		t.Errorf("unexpected release %+v created from %v", created, data)
+	}
+
+	release.ID = created.ID
+	repository.Provider.Update(repository, "token", release)
+
+	expected := []string{"POST /api/v1/repos/owner/repo/releases", "PATCH /api/v1/repos/owner/repo/releases/42"}
+
+	if !reflect.DeepEqual(requests, expected) || data["body"] != "Changes" {
+		t.Errorf("unexpected requests %v with data %v", requests, data)
+	}
+}
+
+func TestGiteaUploadAsset(t *testing.T) {
+	done := useTestFolder(t, map[string]string{"dist/app.bin": "binary"})
+	defer done()
+
+	uploaded := false
+
+	repository, closeServer := useGiteaTestRepository(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/api/v1/repos/owner/repo/releases/10/assets" || r.URL.Query().Get("name") != "app.bin" {
+			http.NotFound(w, r)
+			return
+		}
+
+		file, header, err := r.FormFile("attachment")
+		if err != nil {
+			t.Fatalf("missing attachment: %s", err.Error())
+		}
+
+		contents, _ := ioutil.ReadAll(file)
+		uploaded = string(contents) == "binary" && header.Filename == "app.bin"
+
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id": 3, "name": "app.bin"}`))
+	})
+
+	defer closeServer()
+
+	cwd, _ := os.Getwd()
+	repository.Provider.UploadAsset(repository, "token", &Release{ID: 10}, filepath.Join(cwd, "dist", "app.bin"))
+
+	if !uploaded {
+		t.Error("the asset has not been uploaded")
+	}
+}
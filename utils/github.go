@@ -0,0 +1,295 @@
+/*
+ * This file is part of impacca. Copyright (C) 2013 and above Shogun <shogun@cowtech.it>.
+ * Licensed under the MIT license, which can be found at https://choosealicense.com/licenses/mit.
+ */
+
+package utils
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ShogunPanda/impacca/configuration"
+	"gopkg.in/h2non/gentleman.v2"
+	"gopkg.in/h2non/gentleman.v2/plugins/body"
+)
+
+// githubRelease represents a GitHub API release. Gitea uses the same format.
+type githubRelease struct {
+	ID         int        `json:"id"`
+	TagName    string     `json:"tag_name"`
+	Name       string     `json:"name"`
+	Body       string     `json:"body"`
+	Prerelease bool       `json:"prerelease"`
+	CreatedAt  *time.Time `json:"created_at"`
+}
+
+// githubReleaseAsset represents a GitHub API release asset. Gitea uses the same format.
+type githubReleaseAsset struct {
+	ID                 int    `json:"id"`
+	Name               string `json:"name"`
+	Size               int64  `json:"size"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+}
+
+// githubReleaseProvider manages GitHub releases
+type githubReleaseProvider struct{}
+
+func (r githubRelease) toRelease() *Release {
+	return &Release{
+		ID: r.ID, Tag: r.TagName, Name: r.Name, Version: releaseVersion(r.Name, r.TagName),
+		Date: r.CreatedAt, Body: r.Body, Prerelease: r.Prerelease,
+	}
+}
+
+func (a githubReleaseAsset) toReleaseAsset() ReleaseAsset {
+	return ReleaseAsset{ID: a.ID, Name: a.Name, Size: a.Size, URL: a.BrowserDownloadURL}
+}
+
+// githubReleaseData returns the data to create or update a release using the GitHub (or Gitea) API.
+func githubReleaseData(release *Release) map[string]interface{} {
+	return map[string]interface{}{
+		"tag_name": release.Tag, "name": release.Name, "body": release.Body, "prerelease": release.Prerelease,
+	}
+}
+
+// decodeGithubReleases decodes a list of releases in the GitHub format, skipping the ones which are not versions.
+func decodeGithubReleases(message string, res *gentleman.Response) []Release {
+	var rawReleases []githubRelease
+
+	if err := res.JSON(&rawReleases); err != nil {
+		Fatal("Cannot decode JSON response to %s: {errorPrimary}%s{-}", message, err.Error())
+	}
+
+	releases := make([]Release, 0, len(rawReleases))
+
+	for _, rawRelease := range rawReleases {
+		if release := rawRelease.toRelease(); release.Version != nil {
+			releases = append(releases, *release)
+		}
+	}
+
+	return releases
+}
+
+// decodeGithubRelease decodes a release in the GitHub format.
+func decodeGithubRelease(message string, res *gentleman.Response) *Release {
+	var release githubRelease
+
+	if err := res.JSON(&release); err != nil {
+		Fatal("Cannot decode JSON response to %s: {errorPrimary}%s{-}", message, err.Error())
+	}
+
+	return release.toRelease()
+}
+
+// githubAPIURL returns the API base URL of the GitHub instance hosting a repository.
+// GitHub Enterprise Server instances serve the API in the /api/v3 path, unless github.apiURL is configured.
+func githubAPIURL(repository *Repository) string {
+	if apiURL := configuration.Current.GitHub.APIURL; apiURL != "" {
+		return strings.TrimSuffix(apiURL, "/")
+	} else if repository.BaseURL == "https://github.com" {
+		return "https://api.github.com"
+	}
+
+	return repository.BaseURL + "/api/v3"
+}
+
+// githubUploadsURL returns the base URL used to upload release assets to the GitHub instance hosting a repository.
+func githubUploadsURL(repository *Repository) string {
+	apiURL := githubAPIURL(repository)
+
+	if apiURL == "https://api.github.com" {
+		return "https://uploads.github.com"
+	} else if strings.HasSuffix(apiURL, "/api/v3") {
+		return strings.TrimSuffix(apiURL, "/v3") + "/uploads"
+	}
+
+	return apiURL
+}
+
+// decodeGithubReleaseAssets decodes a list of release assets in the GitHub format.
+func decodeGithubReleaseAssets(message string, res *gentleman.Response) []ReleaseAsset {
+	var rawAssets []githubReleaseAsset
+
+	if err := res.JSON(&rawAssets); err != nil {
+		Fatal("Cannot decode JSON response to %s: {errorPrimary}%s{-}", message, err.Error())
+	}
+
+	assets := make([]ReleaseAsset, 0, len(rawAssets))
+
+	for _, rawAsset := range rawAssets {
+		assets = append(assets, rawAsset.toReleaseAsset())
+	}
+
+	return assets
+}
+
+// decodeGithubReleaseAsset decodes a release asset in the GitHub format.
+func decodeGithubReleaseAsset(message string, res *gentleman.Response) ReleaseAsset {
+	var rawAsset githubReleaseAsset
+
+	if err := res.JSON(&rawAsset); err != nil {
+		Fatal("Cannot decode JSON response to %s: {errorPrimary}%s{-}", message, err.Error())
+	}
+
+	return rawAsset.toReleaseAsset()
+}
+
+// GitHubReleaseAPICall performs a GitHub release API call on a repository. The path is relative to the repository API URL.
+func GitHubReleaseAPICall(message, method string, repository *Repository, path, token string, data map[string]interface{}, allowNotFound bool) *gentleman.Response {
+	cli := gentleman.New()
+
+	req := cli.Request()
+	req.Method(method)
+	req.URL(fmt.Sprintf("%s/repos/%s%s", githubAPIURL(repository), repository.Path, path))
+
+	if token != "" {
+		req.SetHeader("Authorization", fmt.Sprintf("Bearer %s", token))
+	}
+
+	if method != "GET" {
+		req.Use(body.JSON(data))
+	}
+
+	return sendReleaseRequest(message, req, allowNotFound)
+}
+
+// githubBaseURL returns the base URL of the GitHub instance serving a remote host, if any.
+// The instance is either github.com or a configured GitHub Enterprise Server one.
+func githubBaseURL(host, baseURL string) (string, bool) {
+	if host == "github.com" || host == "www.github.com" || host == "ssh.github.com" {
+		return "https://github.com", true
+	}
+
+	return matchHost(configuration.Current.GitHub.Hosts, host, baseURL)
+}
+
+func (p *githubReleaseProvider) Name() string {
+	return githubForge
+}
+
+func (p *githubReleaseProvider) Title() string {
+	return "GitHub"
+}
+
+func (p *githubReleaseProvider) Detect(host, baseURL, path string) *Repository {
+	if githubURL, isGitHub := githubBaseURL(host, baseURL); isGitHub {
+		return &Repository{Provider: p, BaseURL: githubURL, Path: path}
+	}
+
+	return nil
+}
+
+func (p *githubReleaseProvider) List(repository *Repository, token string) []Release {
+	releases := make([]Release, 0)
+
+	for page := 1; ; page++ {
+		res := GitHubReleaseAPICall(
+			"get GitHub releases", "GET", repository, fmt.Sprintf("/releases?per_page=100&page=%d", page), token, map[string]interface{}{}, false,
+		)
+
+		releases = append(releases, decodeGithubReleases("get GitHub releases", res)...)
+
+		if !hasNextPage(res) {
+			return releases
+		}
+	}
+}
+
+func (p *githubReleaseProvider) Get(repository *Repository, token, tag string) *Release {
+	res := GitHubReleaseAPICall(
+		"find a GitHub release", "GET", repository, "/releases/tags/"+url.PathEscape(tag), token, map[string]interface{}{}, true,
+	)
+
+	if res.StatusCode == 404 {
+		return nil
+	}
+
+	return decodeGithubRelease("find a GitHub release", res)
+}
+
+func (p *githubReleaseProvider) Create(repository *Repository, token string, release *Release) *Release {
+	res := GitHubReleaseAPICall("create a GitHub release", "POST", repository, "/releases", token, githubReleaseData(release), false)
+
+	return decodeGithubRelease("create a GitHub release", res)
+}
+
+func (p *githubReleaseProvider) Update(repository *Repository, token string, release *Release) *Release {
+	res := GitHubReleaseAPICall(
+		"update a GitHub release", "PATCH", repository, fmt.Sprintf("/releases/%d", release.ID), token, githubReleaseData(release), false,
+	)
+
+	return decodeGithubRelease("update a GitHub release", res)
+}
+
+func (p *githubReleaseProvider) Delete(repository *Repository, token string, release *Release) {
+	GitHubReleaseAPICall(
+		"delete a GitHub release", "DELETE", repository, fmt.Sprintf("/releases/%d", release.ID), token, map[string]interface{}{}, false,
+	)
+}
+
+func (p *githubReleaseProvider) ListAssets(repository *Repository, token string, release *Release) []ReleaseAsset {
+	assets := make([]ReleaseAsset, 0)
+
+	for page := 1; ; page++ {
+		res := GitHubReleaseAPICall(
+			"get GitHub release assets", "GET", repository, fmt.Sprintf("/releases/%d/assets?per_page=100&page=%d", release.ID, page),
+			token, map[string]interface{}{}, false,
+		)
+
+		assets = append(assets, decodeGithubReleaseAssets("get GitHub release assets", res)...)
+
+		if !hasNextPage(res) {
+			return assets
+		}
+	}
+}
+
+// uploadGitHubAsset uploads a file to a release with a name, using the GitHub uploads API.
+func uploadGitHubAsset(repository *Repository, token string, release *Release, file, name string) ReleaseAsset {
+	source, size := openAssetFile(file)
+	defer source.Close()
+
+	cli := gentleman.New()
+
+	req := cli.Request()
+	req.Method("POST")
+	req.URL(fmt.Sprintf("%s/repos/%s/releases/%d/assets", githubUploadsURL(repository), repository.Path, release.ID))
+	req.SetQuery("name", name)
+	req.SetHeader("Content-Type", "application/octet-stream")
+
+	if token != "" {
+		req.SetHeader("Authorization", fmt.Sprintf("Bearer %s", token))
+	}
+
+	useStreamBody(req, source, size)
+
+	res := sendReleaseRequest("upload a GitHub release asset", req, false)
+	return decodeGithubReleaseAsset("upload a GitHub release asset", res)
+}
+
+func (p *githubReleaseProvider) UploadAsset(repository *Repository, token string, release *Release, file string) {
+	uploadGitHubAsset(repository, token, release, file, filepath.Base(file))
+}
+
+// ReplaceAsset uploads the file with a temporary name, as names must be unique, then deletes the asset and renames the uploaded one.
+func (p *githubReleaseProvider) ReplaceAsset(repository *Repository, token string, release *Release, asset ReleaseAsset, file string) {
+	uploaded := uploadGitHubAsset(repository, token, release, file, temporaryAssetName(asset.Name))
+	p.DeleteAsset(repository, token, release, asset)
+
+	GitHubReleaseAPICall(
+		"rename a GitHub release asset", "PATCH", repository, fmt.Sprintf("/releases/assets/%d", uploaded.ID),
+		token, map[string]interface{}{"name": asset.Name}, false,
+	)
+}
+
+func (p *githubReleaseProvider) DeleteAsset(repository *Repository, token string, release *Release, asset ReleaseAsset) {
+	GitHubReleaseAPICall(
+		"delete a GitHub release asset", "DELETE", repository, fmt.Sprintf("/releases/assets/%d", asset.ID),
+		token, map[string]interface{}{}, false,
+	)
+}
@@ -0,0 +1,213 @@
+/*
+ * This file is part of impacca. Copyright (C) 2013 and above Shogun <shogun@cowtech.it>.
+ * Licensed under the MIT license, which can be found at https://choosealicense.com/licenses/mit.
+ */
+
+package utils
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ShogunPanda/impacca/configuration"
+)
+
+// useGitHubTestRepository returns a repository whose GitHub API is served by a handler.
+func useGitHubTestRepository(handler http.HandlerFunc) (*Repository, func()) {
+	baseURL, done := useTestAPIServer(handler)
+	configuration.Current.GitHub.APIURL = baseURL
+
+	return &Repository{Provider: &githubReleaseProvider{}, BaseURL: baseURL, Path: "owner/repo"}, done
+}
+
+func TestGitHubGetEscapesTag(t *testing.T) {
+	repository, done := useGitHubTestRepository(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/repos/owner/repo/releases/tags/mylib%2Fv1.2.3" {
+			http.NotFound(w, r)
+			return
+		}
+
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.Write([]byte(`{"id": 42, "tag_name": "mylib/v1.2.3", "name": "1.2.3", "body": "Changes", "prerelease": false}`))
+	})
+
+	defer done()
+
+	release := repository.Provider.Get(repository, "token", "mylib/v1.2.3")
+
+	if release == nil || release.ID != 42 || release.Tag != "mylib/v1.2.3" || FormatVersion(release.Version) != "1.2.3" || release.Body != "Changes" {
+		t.Fatalf("unexpected release: %+v", release)
+	}
+
+	if missing := repository.Provider.Get(repository, "token", "mylib/v2.0.0"); missing != nil {
+		t.Errorf("unexpected release: %+v", missing)
+	}
+}
+
+func TestGitHubListFollowsPages(t *testing.T) {
+	var repository *Repository
+
+	repository, done := useGitHubTestRepository(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/owner/repo/releases" || r.URL.Query().Get("per_page") != "100" {
+			http.NotFound(w, r)
+			return
+		}
+
+		switch r.URL.Query().Get("page") {
+		case "1":
+			w.Header().Set("Link", `<`+repository.BaseURL+`/repos/owner/repo/releases?per_page=100&page=2>; rel="next", <`+repository.BaseURL+`/repos/owner/repo/releases?per_page=100&page=2>; rel="last"`)
+			w.Write([]byte(`[{"id": 3, "tag_name": "v1.2.0", "name": "1.2.0"}, {"id": 2, "tag_name": "v1.1.0", "name": "1.1.0"}]`))
+		case "2":
+			w.Header().Set("Link", `<`+repository.BaseURL+`/repos/owner/repo/releases?per_page=100&page=1>; rel="prev"`)
+			w.Write([]byte(`[{"id": 1, "tag_name": "v1.0.0", "name": "1.0.0"}, {"id": 0, "tag_name": "nightly", "name": "Nightly"}]`))
+		default:
+			w.Write([]byte(`[]`))
+		}
+	})
+
+	defer done()
+
+	releases := repository.Provider.List(repository, "token")
+
+	if len(releases) != 3 || releases[0].ID != 3 || releases[2].ID != 1 {
+		t.Errorf("unexpected releases: %+v", releases)
+	}
+}
+
+func TestGitHubListAssetsFollowsPages(t *testing.T) {
+	repository, done := useGitHubTestRepository(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/owner/repo/releases/10/assets" || r.URL.Query().Get("per_page") != "100" {
+			http.NotFound(w, r)
+			return
+		}
+
+		switch r.URL.Query().Get("page") {
+		case "1":
+			w.Header().Set("Link", `<http://localhost/repos/owner/repo/releases/10/assets?per_page=100&page=2>; rel="next"`)
+			w.Write([]byte(`[{"id": 2, "name": "app.bin", "size": 3}]`))
+		case "2":
+			w.Header().Set("Link", `<http://localhost/repos/owner/repo/releases/10/assets?per_page=100&page=1>; rel="prev"`)
+			w.Write([]byte(`[{"id": 1, "name": "app.bin.sha256", "size": 10}]`))
+		default:
+			w.Write([]byte(`[]`))
+		}
+	})
+
+	defer done()
+
+	assets := repository.Provider.ListAssets(repository, "token", &Release{ID: 10})
+
+	if len(assets) != 2 || assets[0].Name != "app.bin" || assets[1].Name != "app.bin.sha256" {
+		t.Errorf("unexpected assets: %+v", assets)
+	}
+}
+
+func TestGitHubUploadAssetAuthorization(t *testing.T) {
+	file, err := ioutil.TempFile("", "impacca-asset")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.Remove(file.Name())
+	file.WriteString("binary")
+	file.Close()
+
+	cases := []struct {
+		token         string
+		authorization string
+	}{
+		{"token", "Bearer token"},
+		{"", ""},
+	}
+
+	for _, c := range cases {
+		var authorization []string
+
+		repository, done := useGitHubTestRepository(func(w http.ResponseWriter, r *http.Request) {
+			authorization = r.Header["Authorization"]
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id": 1, "name": "app.bin"}`))
+		})
+
+		repository.Provider.UploadAsset(repository, c.token, &Release{ID: 10}, file.Name())
+		done()
+
+		if c.authorization == "" && len(authorization) > 0 {
+			t.Errorf("unexpected Authorization header for an empty token: %v", authorization)
+		} else if c.authorization != "" && (len(authorization) != 1 || authorization[0] != c.authorization) {
+			t.Errorf("expected Authorization header %q, got %v", c.authorization, authorization)
+		}
+	}
+}
+
+func TestGitHubUploadReleaseAssetsReplacesAfterUpload(t *testing.T) {
+	folder, err := ioutil.TempDir("", "impacca-assets")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(folder)
+
+	cwd, _ := os.Getwd()
+	defer os.Chdir(cwd)
+
+	os.Chdir(folder)
+	os.Mkdir("dist", 0755)
+	ioutil.WriteFile(filepath.Join("dist", "app.bin"), []byte("binary"), 0644)
+
+	previous := configuration.Current.Release
+	configuration.Current.Release.Assets = []string{"dist/*"}
+	configuration.Current.Release.Checksums = true
+	defer func() { configuration.Current.Release = previous }()
+
+	requests := make([]string, 0)
+
+	repository, done := useGitHubTestRepository(func(w http.ResponseWriter, r *http.Request) {
+		contents, _ := ioutil.ReadAll(r.Body)
+		requests = append(requests, strings.TrimSpace(r.Method+" "+r.URL.Path+" "+r.URL.Query().Get("name")))
+
+		switch {
+		case r.Method == "GET":
+			w.Write([]byte(`[{"id": 1, "name": "app.bin", "size": 3}, {"id": 2, "name": "app.bin.sha256.uploading", "size": 10}]`))
+		case r.Method == "POST":
+			if r.ContentLength != int64(len(contents)) || len(r.TransferEncoding) > 0 {
+				t.Errorf("unexpected length %d for %d bytes", r.ContentLength, len(contents))
+			}
+
+			if r.URL.Query().Get("name") == "app.bin.uploading" && string(contents) != "binary" {
+				t.Errorf("unexpected contents: %s", contents)
+			}
+
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id": 3, "name": "` + r.URL.Query().Get("name") + `"}`))
+		default:
+			w.Write([]byte(`{}`))
+		}
+	})
+
+	defer done()
+
+	UploadReleaseAssets(repository, "token", &Release{ID: 10}, false)
+
+	expected := []string{
+		"GET /repos/owner/repo/releases/10/assets",
+		"DELETE /repos/owner/repo/releases/assets/2",
+		"POST /repos/owner/repo/releases/10/assets app.bin.uploading",
+		"DELETE /repos/owner/repo/releases/assets/1",
+		"PATCH /repos/owner/repo/releases/assets/3",
+		"POST /repos/owner/repo/releases/10/assets app.bin.sha256",
+	}
+
+	if !reflect.DeepEqual(requests, expected) {
+		t.Errorf("unexpected requests: %v", requests)
+	}
+}
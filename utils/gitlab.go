@@ -0,0 +1,272 @@
+/*
+ * This file is part of impacca. Copyright (C) 2013 and above Shogun <shogun@cowtech.it>.
+ * Licensed under the MIT license, which can be found at https://choosealicense.com/licenses/mit.
+ */
+
+package utils
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver"
+	"github.com/ShogunPanda/impacca/configuration"
+	"gopkg.in/h2non/gentleman.v2"
+	"gopkg.in/h2non/gentleman.v2/plugins/body"
+)
+
+// gitlabRelease represents a GitLab API release
+type gitlabRelease struct {
+	Name        string     `json:"name"`
+	TagName     string     `json:"tag_name"`
+	Description string     `json:"description"`
+	ReleasedAt  *time.Time `json:"released_at"`
+}
+
+// gitlabReleaseLink represents a GitLab API release link
+type gitlabReleaseLink struct {
+	ID       int    `json:"id,omitempty"`
+	Name     string `json:"name"`
+	URL      string `json:"url"`
+	LinkType string `json:"link_type,omitempty"`
+}
+
+// gitlabReleaseProvider manages GitLab releases, which are identified by their tag
+type gitlabReleaseProvider struct{}
+
+// gitlabBaseURL returns the base URL of the GitLab instance serving a remote host, if any.
+// The instance is either gitlab.com, a gitlab.* host or a configured self-hosted one.
+func gitlabBaseURL(host, baseURL string) (string, bool) {
+	hostname := strings.Split(host, ":")[0]
+
+	if hostname == "gitlab.com" || strings.HasPrefix(hostname, "gitlab.") {
+		return baseURL, true
+	}
+
+	return matchHost(configuration.Current.GitLab.Hosts, host, baseURL)
+}
+
+// gitlabProjectURL returns the API URL of a project. Nested groups are part of the project path, which must be a single URL segment.
+func gitlabProjectURL(repository *Repository) string {
+	return fmt.Sprintf("%s/api/v4/projects/%s", repository.BaseURL, url.PathEscape(repository.Path))
+}
+
+// GitLabAPICall performs a GitLab API call on a project. The path is relative to the project API URL.
+func GitLabAPICall(message, method string, repository *Repository, path, token string, data interface{}, allowNotFound bool) *gentleman.Response {
+	cli := gentleman.New()
+
+	req := cli.Request()
+	req.Method(method)
+	req.URL(gitlabProjectURL(repository) + path)
+
+	if token != "" {
+		req.SetHeader("PRIVATE-TOKEN", token)
+	}
+
+	if method != "GET" {
+		req.Use(body.JSON(data))
+	}
+
+	return sendReleaseRequest(message, req, allowNotFound)
+}
+
+func (r gitlabRelease) toRelease() *Release {
+	return &Release{
+		Tag: r.TagName, Name: r.Name, Version: releaseVersion(r.Name, r.TagName), Date: r.ReleasedAt, Body: r.Description,
+	}
+}
+
+// decodeGitLabRelease decodes a GitLab release.
+func decodeGitLabRelease(message string, res *gentleman.Response) *Release {
+	var release gitlabRelease
+
+	if err := res.JSON(&release); err != nil {
+		Fatal("Cannot decode JSON response to %s: {errorPrimary}%s{-}", message, err.Error())
+	}
+
+	return release.toRelease()
+}
+
+// gitlabReleaseLinks returns the configured release links of a version.
+func gitlabReleaseLinks(version *semver.Version) []gitlabReleaseLink {
+	tag := VersionTag(FormatVersion(version))
+	replacer := strings.NewReplacer(tagVersionPlaceholder, FormatVersion(version), "{{tag}}", tag)
+	links := make([]gitlabReleaseLink, 0)
+
+	for _, link := range configuration.Current.GitLab.Links {
+		links = append(links, gitlabReleaseLink{Name: link.Name, URL: replacer.Replace(link.URL), LinkType: link.Type})
+	}
+
+	return links
+}
+
+// gitlabReleaseLinksPath returns the API path of the links of a release, relative to the project API URL.
+func gitlabReleaseLinksPath(tag string) string {
+	return fmt.Sprintf("/releases/%s/assets/links", url.PathEscape(tag))
+}
+
+// listGitLabReleaseLinks returns the links of a release.
+func listGitLabReleaseLinks(repository *Repository, tag, token string) []gitlabReleaseLink {
+	res := GitLabAPICall("get GitLab release links", "GET", repository, gitlabReleaseLinksPath(tag), token, nil, false)
+
+	var links []gitlabReleaseLink
+	if err := res.JSON(&links); err != nil {
+		Fatal("Cannot decode JSON response to get GitLab release links: {errorPrimary}%s{-}", err.Error())
+	}
+
+	return links
+}
+
+// saveGitLabReleaseLinks creates or updates links of an existing release, matching them by name.
+func saveGitLabReleaseLinks(repository *Repository, tag, token string, links []gitlabReleaseLink) {
+	linksPath := gitlabReleaseLinksPath(tag)
+	existingLinks := listGitLabReleaseLinks(repository, tag, token)
+
+	for _, link := range links {
+		var existing *gitlabReleaseLink
+
+		for i := range existingLinks {
+			if existingLinks[i].Name == link.Name {
+				existing = &existingLinks[i]
+				break
+			}
+		}
+
+		if existing == nil {
+			GitLabAPICall("create a GitLab release link", "POST", repository, linksPath, token, link, false)
+		} else if existing.URL != link.URL || (link.LinkType != "" && existing.LinkType != link.LinkType) {
+			GitLabAPICall(
+				"update a GitLab release link", "PUT", repository, fmt.Sprintf("%s/%d", linksPath, existing.ID), token, link, false,
+			)
+		}
+	}
+}
+
+func (p *gitlabReleaseProvider) Name() string {
+	return gitlabForge
+}
+
+func (p *gitlabReleaseProvider) Title() string {
+	return "GitLab"
+}
+
+func (p *gitlabReleaseProvider) Detect(host, baseURL, path string) *Repository {
+	if gitlabURL, isGitLab := gitlabBaseURL(host, baseURL); isGitLab {
+		return &Repository{Provider: p, BaseURL: gitlabURL, Path: path}
+	}
+
+	return nil
+}
+
+func (p *gitlabReleaseProvider) List(repository *Repository, token string) []Release {
+	releases := make([]Release, 0)
+
+	for page := 1; ; page++ {
+		res := GitLabAPICall("get GitLab releases", "GET", repository, fmt.Sprintf("/releases?per_page=100&page=%d", page), token, nil, false)
+
+		var rawReleases []gitlabRelease
+		if err := res.JSON(&rawReleases); err != nil {
+			Fatal("Cannot decode JSON response to get GitLab releases: {errorPrimary}%s{-}", err.Error())
+		}
+
+		for _, rawRelease := range rawReleases {
+			if release := rawRelease.toRelease(); release.Version != nil {
+				releases = append(releases, *release)
+			}
+		}
+
+		if !hasNextPage(res) {
+			return releases
+		}
+	}
+}
+
+func (p *gitlabReleaseProvider) Get(repository *Repository, token, tag string) *Release {
+	res := GitLabAPICall("find a GitLab release", "GET", repository, "/releases/"+url.PathEscape(tag), token, nil, true)
+
+	if res.StatusCode == 404 {
+		return nil
+	}
+
+	return decodeGitLabRelease("find a GitLab release", res)
+}
+
+// Create creates a release with the configured links.
+func (p *gitlabReleaseProvider) Create(repository *Repository, token string, release *Release) *Release {
+	data := map[string]interface{}{
+		"tag_name": release.Tag, "name": release.Name, "description": release.Body,
+		"assets": map[string]interface{}{"links": gitlabReleaseLinks(release.Version)},
+	}
+
+	res := GitLabAPICall("create a GitLab release", "POST", repository, "/releases", token, data, false)
+	return decodeGitLabRelease("create a GitLab release", res)
+}
+
+// Update updates a release and its configured links.
+func (p *gitlabReleaseProvider) Update(repository *Repository, token string, release *Release) *Release {
+	data := map[string]interface{}{"name": release.Name, "description": release.Body}
+	res := GitLabAPICall("update a GitLab release", "PUT", repository, "/releases/"+url.PathEscape(release.Tag), token, data, false)
+
+	if links := gitlabReleaseLinks(release.Version); len(links) > 0 {
+		saveGitLabReleaseLinks(repository, release.Tag, token, links)
+	}
+
+	return decodeGitLabRelease("update a GitLab release", res)
+}
+
+func (p *gitlabReleaseProvider) Delete(repository *Repository, token string, release *Release) {
+	GitLabAPICall("delete a GitLab release", "DELETE", repository, "/releases/"+url.PathEscape(release.Tag), token, nil, false)
+}
+
+// ListAssets returns the links of a release, as GitLab releases have no attached files.
+func (p *gitlabReleaseProvider) ListAssets(repository *Repository, token string, release *Release) []ReleaseAsset {
+	links := listGitLabReleaseLinks(repository, release.Tag, token)
+	assets := make([]ReleaseAsset, 0, len(links))
+
+	for _, link := range links {
+		assets = append(assets, ReleaseAsset{ID: link.ID, Name: link.Name, URL: link.URL})
+	}
+
+	return assets
+}
+
+// UploadAsset uploads a file to the generic packages registry of the project and links it to the release.
+func (p *gitlabReleaseProvider) UploadAsset(repository *Repository, token string, release *Release, file string) {
+	source, size := openAssetFile(file)
+	defer source.Close()
+
+	name := filepath.Base(file)
+	packageURL := fmt.Sprintf(
+		"%s/packages/generic/%s/%s/%s",
+		gitlabProjectURL(repository), url.PathEscape(path.Base(repository.Path)), url.PathEscape(FormatVersion(release.Version)),
+		url.PathEscape(name),
+	)
+
+	cli := gentleman.New()
+
+	req := cli.Request()
+	req.Method("PUT")
+	req.URL(packageURL)
+	req.SetHeader("PRIVATE-TOKEN", token)
+	useStreamBody(req, source, size)
+
+	sendReleaseRequest("upload a GitLab release asset", req, false)
+	saveGitLabReleaseLinks(repository, release.Tag, token, []gitlabReleaseLink{{Name: name, URL: packageURL, LinkType: "package"}})
+}
+
+// ReplaceAsset uploads the file again and keeps the link, as the generic packages registry serves the latest file with the same name.
+func (p *gitlabReleaseProvider) ReplaceAsset(repository *Repository, token string, release *Release, asset ReleaseAsset, file string) {
+	p.UploadAsset(repository, token, release, file)
+}
+
+// DeleteAsset deletes a release link. Files uploaded to the generic packages registry are kept.
+func (p *gitlabReleaseProvider) DeleteAsset(repository *Repository, token string, release *Release, asset ReleaseAsset) {
+	GitLabAPICall(
+		"delete a GitLab release link", "DELETE", repository, fmt.Sprintf("%s/%d", gitlabReleaseLinksPath(release.Tag), asset.ID),
+		token, nil, false,
+	)
+}
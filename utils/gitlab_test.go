@@ -0,0 +1,225 @@
+/*
+ * This file is part of impacca. Copyright (C) 2013 and above Shogun <shogun@cowtech.it>.
+ * Licensed under the MIT license, which can be found at https://choosealicense.com/licenses/mit.
+ */
+
+package utils
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Masterminds/semver"
+	"github.com/ShogunPanda/impacca/configuration"
+)
+
+const gitlabTestProjectPath = "/api/v4/projects/group%2Fsub%2Fproject"
+
+// useGitLabTestRepository returns a repository whose GitLab API is served by a handler.
+func useGitLabTestRepository(handler http.HandlerFunc) (*Repository, func()) {
+	baseURL, done := useTestAPIServer(handler)
+	return &Repository{Provider: &gitlabReleaseProvider{}, BaseURL: baseURL, Path: "group/sub/project"}, done
+}
+
+func TestGitLabDetect(t *testing.T) {
+	previous := configuration.Current.GitLab
+	configuration.Current.GitLab.Hosts = []string{"git.example.com", "localhost:8080"}
+	defer func() { configuration.Current.GitLab = previous }()
+
+	cases := []struct {
+		remote  string
+		baseURL string
+		path    string
+	}{
+		{"https://gitlab.com/group/sub/project.git", "https://gitlab.com", "group/sub/project"},
+		{"https://gitlab.example.com/group/project", "https://gitlab.example.com", "group/project"},
+		{"https://git.example.com/group/sub/project.git", "https://git.example.com", "group/sub/project"},
+		{"http://localhost:8080/group/project.git", "http://localhost:8080", "group/project"},
+		{"https://github.com/owner/repo.git", "", ""},
+		{"https://git.other.com/owner/repo.git", "", ""},
+	}
+
+	provider := &gitlabReleaseProvider{}
+
+	for _, c := range cases {
+		repository := detectTestRemote(provider, c.remote)
+
+		if c.baseURL == "" {
+			if repository != nil {
+				t.Errorf("%s: unexpected repository %+v", c.remote, repository)
+			}
+
+			continue
+		}
+
+		if repository == nil || repository.BaseURL != c.baseURL || repository.Path != c.path {
+			t.Errorf("%s: unexpected repository %+v", c.remote, repository)
+		}
+	}
+}
+
+func TestGitLabGet(t *testing.T) {
+	repository, done := useGitLabTestRepository(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != gitlabTestProjectPath+"/releases/v1.2.3" {
+			http.NotFound(w, r)
+			return
+		}
+
+		if r.Header.Get("PRIVATE-TOKEN") != "token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.Write([]byte(`{"tag_name": "v1.2.3", "name": "1.2.3", "description": "Changes", "released_at": "2019-01-02T03:04:05Z"}`))
+	})
+
+	defer done()
+
+	release := repository.Provider.Get(repository, "token", "v1.2.3")
+
+	if release == nil || release.Tag != "v1.2.3" || FormatVersion(release.Version) != "1.2.3" || release.Body != "Changes" ||
+		release.Date == nil || release.Date.Format("2006-01-02") != "2019-01-02" {
+		t.Fatalf("unexpected release: %+v", release)
+	}
+
+	if missing := repository.Provider.Get(repository, "token", "v2.0.0"); missing != nil {
+		t.Errorf("unexpected release: %+v", missing)
+	}
+}
+
+func TestGitLabListFollowsPages(t *testing.T) {
+	repository, done := useGitLabTestRepository(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != gitlabTestProjectPath+"/releases" || r.URL.Query().Get("per_page") != "100" {
+			http.NotFound(w, r)
+			return
+		}
+
+		switch r.URL.Query().Get("page") {
+		case "1":
+			w.Header().Set("Link", `<http://localhost/releases?page=2>; rel="next", <http://localhost/releases?page=2>; rel="last"`)
+			w.Write([]byte(`[{"tag_name": "v1.2.0", "name": "1.2.0"}, {"tag_name": "nightly", "name": "Nightly"}]`))
+		case "2":
+			w.Header().Set("Link", `<http://localhost/releases?page=1>; rel="first"`)
+			w.Write([]byte(`[{"tag_name": "v1.1.0", "name": "Second release"}]`))
+		default:
+			w.Write([]byte(`[]`))
+		}
+	})
+
+	defer done()
+
+	releases := repository.Provider.List(repository, "token")
+
+	if len(releases) != 2 || FormatVersion(releases[0].Version) != "1.2.0" || FormatVersion(releases[1].Version) != "1.1.0" {
+		t.Errorf("unexpected releases: %+v", releases)
+	}
+}
+
+func TestGitLabCreateAndUpdateLinks(t *testing.T) {
+	requests := make([]string, 0)
+	var created map[string]interface{}
+
+	repository, done := useGitLabTestRepository(func(w http.ResponseWriter, r *http.Request) {
+		contents, _ := ioutil.ReadAll(r.Body)
+		requests = append(requests, strings.TrimSpace(r.Method+" "+r.URL.EscapedPath()+" "+string(contents)))
+
+		switch {
+		case r.Method == "POST" && r.URL.EscapedPath() == gitlabTestProjectPath+"/releases":
+			json.Unmarshal(contents, &created)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"tag_name": "v1.2.3", "name": "1.2.3"}`))
+		case r.Method == "GET":
+			w.Write([]byte(`[{"id": 7, "name": "Docs", "url": "https://docs.example.com/1.2.2"}, {"id": 8, "name": "Image", "url": "https://registry.example.com/app:v1.2.3"}]`))
+		default:
+			w.Write([]byte(`{"tag_name": "v1.2.3", "name": "1.2.3"}`))
+		}
+	})
+
+	defer done()
+
+	configuration.Current.GitLab.Links = []configuration.ReleaseLink{
+		{Name: "Docs", URL: "https://docs.example.com/{{version}}"},
+		{Name: "Image", URL: "https://registry.example.com/app:{{tag}}"},
+		{Name: "Download", URL: "https://example.com/{{tag}}.zip", Type: "other"},
+	}
+
+	version := semver.MustParse("1.2.3")
+	repository.Provider.Create(repository, "token", &Release{Tag: "v1.2.3", Name: "1.2.3", Version: version, Body: "Changes"})
+
+	links := created["assets"].(map[string]interface{})["links"].([]interface{})
+	if created["tag_name"] != "v1.2.3" || created["description"] != "Changes" || len(links) != 3 ||
+		links[0].(map[string]interface{})["url"] != "https://docs.example.com/1.2.3" {
+		t.Errorf("unexpected release: %v", created)
+	}
+
+	requests = requests[:0]
+	repository.Provider.Update(repository, "token", &Release{Tag: "v1.2.3", Name: "1.2.3", Version: version, Body: "More changes"})
+
+	expected := []string{
+		"PUT " + gitlabTestProjectPath + `/releases/v1.2.3 {"description":"More changes","name":"1.2.3"}`,
+		"GET " + gitlabTestProjectPath + "/releases/v1.2.3/assets/links",
+		"PUT " + gitlabTestProjectPath + `/releases/v1.2.3/assets/links/7 {"name":"Docs","url":"https://docs.example.com/1.2.3"}`,
+		"POST " + gitlabTestProjectPath + `/releases/v1.2.3/assets/links {"name":"Download","url":"https://example.com/v1.2.3.zip","link_type":"other"}`,
+	}
+
+	if !reflect.DeepEqual(requests, expected) {
+		t.Errorf("unexpected requests:\n%v", requests)
+	}
+}
+
+func TestGitLabUploadAsset(t *testing.T) {
+	done := useTestFolder(t, map[string]string{"dist/app.bin": "binary"})
+	defer done()
+
+	requests := make([]string, 0)
+	packagePath := gitlabTestProjectPath + "/packages/generic/project/1.2.3/app.bin"
+
+	repository, closeServer := useGitLabTestRepository(func(w http.ResponseWriter, r *http.Request) {
+		contents, _ := ioutil.ReadAll(r.Body)
+		requests = append(requests, r.Method+" "+r.URL.EscapedPath())
+
+		switch {
+		case r.Method == "PUT":
+			if r.Header.Get("PRIVATE-TOKEN") != "token" || string(contents) != "binary" || r.ContentLength != 6 || len(r.TransferEncoding) > 0 {
+				t.Errorf("unexpected upload: %s (%d bytes)", contents, r.ContentLength)
+			}
+
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"message": "201 Created"}`))
+		case r.Method == "GET":
+			w.Write([]byte(`[]`))
+		default:
+			var link gitlabReleaseLink
+			json.Unmarshal(contents, &link)
+
+			if link.Name != "app.bin" || link.URL != "http://"+r.Host+packagePath || link.LinkType != "package" {
+				t.Errorf("unexpected link: %+v", link)
+			}
+
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{}`))
+		}
+	})
+
+	defer closeServer()
+
+	cwd, _ := os.Getwd()
+	release := &Release{Tag: "v1.2.3", Name: "1.2.3", Version: semver.MustParse("1.2.3")}
+	repository.Provider.UploadAsset(repository, "token", release, filepath.Join(cwd, "dist", "app.bin"))
+
+	expected := []string{
+		"PUT " + packagePath,
+		"GET " + gitlabTestProjectPath + "/releases/v1.2.3/assets/links",
+		"POST " + gitlabTestProjectPath + "/releases/v1.2.3/assets/links",
+	}
+
+	if !reflect.DeepEqual(requests, expected) {
+		t.Errorf("unexpected requests: %v", requests)
+	}
+}
@@ -0,0 +1,211 @@
+/*
+ * This file is part of impacca. Copyright (C) 2013 and above Shogun <shogun@cowtech.it>.
+ * Licensed under the MIT license, which can be found at https://choosealicense.com/licenses/mit.
+ */
+
+package utils
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/ShogunPanda/impacca/configuration"
+)
+
+var goMajorSuffixMatcher = regexp.MustCompile("/v\\d+$")
+var goModuleTagPrefix *string
+
+// goModulePath returns the module path declared in the go.mod file of a folder.
+func goModulePath(folder string) string {
+	rawModule, err := ioutil.ReadFile(filepath.Join(folder, "go.mod"))
+
+	if err != nil {
+		Fatal("Cannot read file {errorPrimary}go.mod{-}: {errorPrimary}%s{-}", err.Error())
+	}
+
+	matches := goModuleMatcher.FindSubmatch(rawModule)
+
+	if matches == nil {
+		Fatal("Cannot find the module path in the file {errorPrimary}go.mod{-}.")
+	}
+
+	return string(matches[1])
+}
+
+// expectedGoModulePath returns the module path required by a major version: starting from v2 it must end with /vN.
+func expectedGoModulePath(modulePath string, major int64) string {
+	base := goMajorSuffixMatcher.ReplaceAllString(modulePath, "")
+
+	if major < 2 {
+		return base
+	}
+
+	return fmt.Sprintf("%s/v%d", base, major)
+}
+
+// goSubmoduleFolder returns the folder of the current Go module relative to the repository root, if it is not the root.
+// Go requires tags of these modules to be prefixed by the folder, like tools/v1.2.3.
+func goSubmoduleFolder() string {
+	if goModuleTagPrefix == nil {
+		prefix := ""
+		cwd, _ := os.Getwd()
+
+		if _, err := os.Stat(filepath.Join(cwd, "go.mod")); err == nil {
+			cwd, _ = filepath.EvalSymlinks(cwd)
+			root, _ := filepath.EvalSymlinks(GetRepositoryRoot())
+
+			if relative, err := filepath.Rel(root, cwd); err == nil && relative != "." {
+				prefix = filepath.ToSlash(relative)
+			}
+		}
+
+		goModuleTagPrefix = &prefix
+	}
+
+	return *goModuleTagPrefix
+}
+
+// goSourceFiles returns all Go files of the module in a folder, excluding nested modules.
+func goSourceFiles(root string) []string {
+	files := make([]string, 0)
+
+	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+
+		if info.IsDir() && path != root {
+			if ignoredWorkspaceFolders[info.Name()] || strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			} else if _, err := os.Stat(filepath.Join(path, "go.mod")); err == nil {
+				return filepath.SkipDir
+			}
+		}
+
+		if !info.IsDir() && strings.HasSuffix(info.Name(), ".go") {
+			files = append(files, path)
+		}
+
+		return nil
+	})
+
+	return files
+}
+
+// goPackageManager releases Go modules using Git
+type goPackageManager struct {
+	changedFiles
+}
+
+// rewriteGoModule changes the module path in go.mod and in all internal imports.
+func (m *goPackageManager) rewriteGoModule(root, previousPath, newPath string, dryRun bool) {
+	sources := goSourceFiles(root)
+	m.trackFile(filepath.Join(root, "go.mod"))
+
+	if !NotifyExecution(
+		dryRun, "Will rewrite", "Rewriting", " the module path {primary}%s{-} to {primary}%s{-} in {primary}go.mod{-} and {primary}%d{-} Go file(s) ...",
+		previousPath, newPath, len(sources),
+	) {
+		return
+	}
+
+	importMatcher := regexp.MustCompile("\"" + regexp.QuoteMeta(previousPath) + "(/[^\"]*)?\"")
+	moduleMatcher := regexp.MustCompile("(?m)^module\\s+\\S+")
+
+	for _, file := range append(sources, filepath.Join(root, "go.mod")) {
+		rawContents, err := ioutil.ReadFile(file)
+
+		if err != nil {
+			Fatal("Cannot read file {errorPrimary}%s{-}: {errorPrimary}%s{-}", file, err.Error())
+		}
+
+		contents := string(rawContents)
+		updated := contents
+
+		if filepath.Base(file) == "go.mod" {
+			updated = moduleMatcher.ReplaceAllString(updated, "module "+newPath)
+		} else {
+			updated = importMatcher.ReplaceAllString(updated, "\""+newPath+"$1\"")
+		}
+
+		if updated == contents {
+			continue
+		} else if filepath.Base(file) != "go.mod" {
+			m.trackFile(file)
+		}
+
+		if err := ioutil.WriteFile(file, []byte(updated), 0644); err != nil {
+			Fatal("Cannot update file {errorPrimary}%s{-}: {errorPrimary}%s{-}", file, err.Error())
+		}
+	}
+}
+
+func (m *goPackageManager) Name() string {
+	return "go"
+}
+
+func (m *goPackageManager) Detect(folder string) bool {
+	_, err := os.Stat(filepath.Join(folder, "go.mod"))
+	return err == nil
+}
+
+// CurrentVersion returns an empty string as Go modules versions are only tracked by GIT tags.
+func (m *goPackageManager) CurrentVersion() string {
+	return ""
+}
+
+// SetVersion enforces the /vN rule of module paths and optionally tidies and vets the module.
+func (m *goPackageManager) SetVersion(newVersion, currentVersion *semver.Version, dryRun bool) {
+	cwd, _ := os.Getwd()
+	modulePath := goModulePath(cwd)
+	expectedPath := expectedGoModulePath(modulePath, newVersion.Major())
+	m.resetFiles()
+
+	if configuration.Current.Go.EnforceModulePath && modulePath != expectedPath {
+		if strings.HasPrefix(modulePath, "gopkg.in/") {
+			Warn("The module path {secondary}%s{-} uses gopkg.in, make sure it matches the version {secondary}%s{-}.", modulePath, FormatVersion(newVersion))
+		} else {
+			rewrite := configuration.Current.Go.RewriteModulePath || dryRun || Confirm(
+				"The module path {primary}%s{-} must be {primary}%s{-} for version {primary}%s{-}. Rewrite it, including internal imports?",
+				modulePath, expectedPath, FormatVersion(newVersion),
+			)
+
+			if !rewrite {
+				Fatal(
+					"The module path {errorPrimary}%s{-} must be {errorPrimary}%s{-} for version {errorPrimary}%s{-}. "+
+						"Set go.rewriteModulePath to true in the configuration to rewrite it automatically.",
+					modulePath, expectedPath, FormatVersion(newVersion),
+				)
+			}
+
+			m.rewriteGoModule(cwd, modulePath, expectedPath, dryRun)
+		}
+	}
+
+	if configuration.Current.Go.Tidy {
+		if NotifyExecution(dryRun, "Will execute", "Executing", ": {primary}go mod tidy{-} ...") {
+			result := Execute(true, "go", "mod", "tidy")
+			result.Verify("go", "Cannot tidy the Go module")
+		}
+
+		m.trackFile(filepath.Join(cwd, "go.mod"))
+
+		if _, err := os.Stat(filepath.Join(cwd, "go.sum")); err == nil {
+			m.trackFile(filepath.Join(cwd, "go.sum"))
+		}
+	}
+
+	if configuration.Current.Go.Vet && NotifyExecution(dryRun, "Will execute", "Executing", ": {primary}go vet ./...{-} ...") {
+		result := Execute(true, "go", "vet", "./...")
+		result.Verify("go", "The Go module did not pass go vet")
+	}
+}
+
+// Publish does nothing as Go modules are published by pushing GIT tags.
+func (m *goPackageManager) Publish(version *semver.Version, options PublishOptions) {
+}
@@ -0,0 +1,130 @@
+/*
+ * This file is part of impacca. Copyright (C) 2013 and above Shogun <shogun@cowtech.it>.
+ * Licensed under the MIT license, which can be found at https://choosealicense.com/licenses/mit.
+ */
+
+package utils
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/Masterminds/semver"
+	"github.com/ShogunPanda/impacca/configuration"
+)
+
+func TestExpectedGoModulePath(t *testing.T) {
+	cases := []struct {
+		path     string
+		major    int64
+		expected string
+	}{
+		{"example.com/mod", 0, "example.com/mod"},
+		{"example.com/mod", 1, "example.com/mod"},
+		{"example.com/mod", 2, "example.com/mod/v2"},
+		{"example.com/mod/v2", 3, "example.com/mod/v3"},
+		{"example.com/mod/v3", 1, "example.com/mod"},
+		{"example.com/v2ray", 2, "example.com/v2ray/v2"},
+	}
+
+	for _, tc := range cases {
+		if actual := expectedGoModulePath(tc.path, tc.major); actual != tc.expected {
+			t.Errorf("%s for major %d: expected %s, got %s", tc.path, tc.major, tc.expected, actual)
+		}
+	}
+}
+
+func TestRewriteGoModule(t *testing.T) {
+	defer useTestFolder(t, map[string]string{
+		"go.mod":               "module example.com/mod\n\ngo 1.12\n\nrequire example.com/modular v1.0.0\n",
+		"main.go":              "package main\n\nimport (\n\t\"example.com/mod/pkg\"\n\t\"example.com/modular\"\n\t\"example.com/mod\"\n)\n",
+		"pkg/pkg.go":           "package pkg\n\n// See example.com/mod/pkg\nimport \"example.com/mod/internal\"\n",
+		"vendor/dep/dep.go":    "package dep\n\nimport \"example.com/mod/pkg\"\n",
+		"tools/go.mod":         "module example.com/mod/tools\n",
+		"tools/tools.go":       "package tools\n\nimport \"example.com/mod/pkg\"\n",
+		".hidden/generated.go": "package hidden\n\nimport \"example.com/mod/pkg\"\n",
+	})()
+
+	cwd, _ := os.Getwd()
+	(&goPackageManager{}).rewriteGoModule(cwd, "example.com/mod", "example.com/mod/v2", false)
+
+	expected := map[string]string{
+		"go.mod":               "module example.com/mod/v2\n\ngo 1.12\n\nrequire example.com/modular v1.0.0\n",
+		"main.go":              "package main\n\nimport (\n\t\"example.com/mod/v2/pkg\"\n\t\"example.com/modular\"\n\t\"example.com/mod/v2\"\n)\n",
+		"pkg/pkg.go":           "package pkg\n\n// See example.com/mod/pkg\nimport \"example.com/mod/v2/internal\"\n",
+		"vendor/dep/dep.go":    "package dep\n\nimport \"example.com/mod/pkg\"\n",
+		"tools/go.mod":         "module example.com/mod/tools\n",
+		"tools/tools.go":       "package tools\n\nimport \"example.com/mod/pkg\"\n",
+		".hidden/generated.go": "package hidden\n\nimport \"example.com/mod/pkg\"\n",
+	}
+
+	names := make([]string, 0, len(expected))
+	for name := range expected {
+		names = append(names, name)
+	}
+
+	if actual := readTestFiles(t, names...); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("unexpected files: %v", actual)
+	}
+}
+
+func TestGoSubmoduleTags(t *testing.T) {
+	defer useTestGitRepository(t)()
+
+	writeTestFiles(t, map[string]string{"go.mod": "module example.com/mod\n", "tools/go.mod": "module example.com/mod/tools\n"})
+	defer func() { goModuleTagPrefix = nil }()
+
+	cases := map[string]string{".": "v1.2.3", "tools": "tools/v1.2.3"}
+
+	for folder, expected := range cases {
+		cwd, _ := os.Getwd()
+		os.Chdir(folder)
+		goModuleTagPrefix = nil
+
+		if actual := VersionTag("1.2.3"); actual != expected {
+			t.Errorf("%s: expected tag %s, got %s", folder, expected, actual)
+		}
+
+		os.Chdir(cwd)
+	}
+}
+
+func TestReleaseGoVersionRewritesModulePath(t *testing.T) {
+	defer useTestGitRepository(t, "v1.2.3")()
+
+	writeTestFiles(t, map[string]string{"go.mod": "module example.com/mod\n", "main.go": "package main\n\nimport \"example.com/mod/pkg\"\n"})
+	runTestGit(t, "add", "--all")
+	runTestGit(t, "commit", "--quiet", "--message=feat: Initial module")
+
+	previous, previousFiles := configuration.Current.Go, configuration.Current.VersionFiles
+	configuration.Current.Go.EnforceModulePath = true
+	configuration.Current.Go.RewriteModulePath = true
+	configuration.Current.VersionFiles = []configuration.VersionFile{}
+
+	defer func() {
+		configuration.Current.Go, configuration.Current.VersionFiles = previous, previousFiles
+		goModuleTagPrefix = nil
+	}()
+
+	if manager := DetectPackageManager(); manager.Name() != "go" {
+		t.Errorf("unexpected package manager %s", manager.Name())
+	}
+
+	ReleaseVersion(DetectPackageManager(), semver.MustParse("2.0.0"), semver.MustParse("1.2.3"), false)
+
+	if files := readTestFiles(t, "go.mod", "main.go"); files["go.mod"] != "module example.com/mod/v2\n" || files["main.go"] != "package main\n\nimport \"example.com/mod/v2/pkg\"\n" {
+		t.Errorf("unexpected files: %v", files)
+	}
+
+	if message := runTestGit(t, "log", "-n", "1", "--format=%s", "v2.0.0"); message != "Version 2.0.0." {
+		t.Errorf("unexpected versioning commit %q", message)
+	}
+
+	// Without changes, only the tag is created
+	ReleaseVersion(DetectPackageManager(), semver.MustParse("2.0.1"), semver.MustParse("2.0.0"), false)
+
+	if runTestGit(t, "rev-parse", "v2.0.1") != runTestGit(t, "rev-parse", "v2.0.0") {
+		t.Error("an empty versioning commit has been created")
+	}
+}
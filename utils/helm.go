@@ -0,0 +1,154 @@
+/*
+ * This file is part of impacca. Copyright (C) 2013 and above Shogun <shogun@cowtech.it>.
+ * Licensed under the MIT license, which can be found at https://choosealicense.com/licenses/mit.
+ */
+
+package utils
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/ShogunPanda/impacca/configuration"
+	"gopkg.in/h2non/gentleman.v2"
+)
+
+var helmChartNameMatcher = regexp.MustCompile("(?m)^name:\\s*[\"']?([^\"'\\s#]+)")
+var helmChartVersionMatcher = regexp.MustCompile("(?m)^version:\\s*[\"']?([^\"'\\s#]+)")
+
+// helmPackageManager releases Helm charts
+type helmPackageManager struct {
+	changedFiles
+}
+
+func (m *helmPackageManager) Name() string {
+	return "helm"
+}
+
+func (m *helmPackageManager) Detect(folder string) bool {
+	_, err := os.Stat(filepath.Join(folder, "Chart.yaml"))
+	return err == nil
+}
+
+func (m *helmPackageManager) CurrentVersion() string {
+	cwd, _ := os.Getwd()
+
+	if matches := helmChartVersionMatcher.FindStringSubmatch(readTextFile(filepath.Join(cwd, "Chart.yaml"))); matches != nil {
+		return matches[1]
+	}
+
+	return ""
+}
+
+// SetVersion updates the version of a Helm chart and, if configured, the version of the application.
+func (m *helmPackageManager) SetVersion(newVersion, currentVersion *semver.Version, dryRun bool) {
+	cwd, _ := os.Getwd()
+	m.resetFiles()
+	chartPath := filepath.Join(cwd, "Chart.yaml")
+	version := FormatVersion(newVersion)
+	keys := []string{"version"}
+
+	if configuration.Current.Helm.AppVersion {
+		keys = append(keys, "appVersion")
+	}
+
+	contents := readTextFile(chartPath)
+	updated := contents
+
+	for _, key := range keys {
+		var count int
+		updated, count, _ = replaceYAML(updated, key, version)
+
+		if count != 1 {
+			Fatal("Cannot find the {errorPrimary}%s{-} of the chart in {errorPrimary}Chart.yaml{-}.", key)
+		}
+	}
+
+	if updated != contents {
+		writeUpdatedFile(chartPath, "Chart.yaml", contents, updated, dryRun)
+		m.trackFile(chartPath)
+	}
+}
+
+// Publish packages the chart and uploads it to the configured repository.
+func (m *helmPackageManager) Publish(version *semver.Version, options PublishOptions) {
+	destination := filepath.Join(os.TempDir(), fmt.Sprintf("impacca-chart-%d", os.Getpid()))
+	defer os.RemoveAll(destination)
+
+	pushHelmChart(packageHelmChart(version, destination, options.DryRun), options.DryRun)
+}
+
+// packageHelmChart packages the Helm chart in a folder, returning the path of the archive.
+func packageHelmChart(version *semver.Version, destination string, dryRun bool) string {
+	cwd, _ := os.Getwd()
+	matches := helmChartNameMatcher.FindStringSubmatch(readTextFile(filepath.Join(cwd, "Chart.yaml")))
+
+	if matches == nil {
+		Fatal("Cannot find the {errorPrimary}name{-} of the chart in {errorPrimary}Chart.yaml{-}.")
+	}
+
+	archive := filepath.Join(destination, fmt.Sprintf("%s-%s.tgz", matches[1], FormatVersion(version)))
+
+	if NotifyExecution(dryRun, "Will execute", "Executing", ": {primary}helm package . --destination %s{-} ...", destination) {
+		result := Execute(true, "helm", "package", cwd, "--destination", destination)
+		result.Verify("helm", "Cannot package the chart")
+	}
+
+	return archive
+}
+
+// pushHelmChart uploads a chart archive to the configured repository.
+// OCI registries are handled by helm, while other repositories must support the ChartMuseum API.
+func pushHelmChart(archive string, dryRun bool) {
+	repository := strings.TrimSuffix(configuration.Current.Helm.Repository, "/")
+
+	if repository == "" {
+		Warn("No chart repository configured, the chart will not be uploaded.")
+		return
+	}
+
+	if strings.HasPrefix(repository, "oci://") {
+		if NotifyExecution(dryRun, "Will execute", "Executing", ": {primary}helm push %s %s{-} ...", filepath.Base(archive), repository) {
+			result := Execute(true, "helm", "push", archive, repository)
+			result.Verify("helm", "Cannot push the chart")
+		}
+
+		return
+	}
+
+	if !NotifyStep(dryRun, "", "Will upload", "Uploading", " the chart {primary}%s{-} to {primary}%s{-} ...", filepath.Base(archive), repository) {
+		return
+	}
+
+	file, err := os.Open(archive)
+
+	if err != nil {
+		Fatal("Cannot read the chart archive {errorPrimary}%s{-}: {errorPrimary}%s{-}", archive, err.Error())
+	}
+
+	defer file.Close()
+
+	req := gentleman.New().Request()
+	req.Method("POST")
+	req.URL(repository + "/api/charts")
+	req.SetHeader("Content-Type", "application/gzip")
+	req.Body(file)
+
+	if username := os.Getenv("IMPACCA_HELM_USERNAME"); username != "" {
+		credentials := base64.StdEncoding.EncodeToString([]byte(username + ":" + os.Getenv("IMPACCA_HELM_PASSWORD")))
+		req.SetHeader("Authorization", "Basic "+credentials)
+	}
+
+	res, err := req.Send()
+
+	if err != nil {
+		Fatal("Cannot upload the chart due to a network error: {errorPrimary}%s{-}", err.Error())
+	} else if !res.Ok {
+		Fatal("Cannot upload the chart due to an HTTP error: {secondary}[HTTP %d]{-} {errorPrimary}%s{-}", res.StatusCode, res.String())
+	}
+}
@@ -0,0 +1,119 @@
+/*
+ * This file is part of impacca. Copyright (C) 2013 and above Shogun <shogun@cowtech.it>.
+ * Licensed under the MIT license, which can be found at https://choosealicense.com/licenses/mit.
+ */
+
+package utils
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Masterminds/semver"
+	"github.com/ShogunPanda/impacca/configuration"
+)
+
+func TestHelmSetVersion(t *testing.T) {
+	done := useTestFolder(t, map[string]string{
+		"Chart.yaml": "apiVersion: v2\nname: app\nversion: 1.2.3 # The chart version\nappVersion: \"1.2.3\"\ndependencies:\n  - name: db\n    version: 1.0.0\n",
+	})
+	defer done()
+
+	previous := configuration.Current.Helm
+	defer func() { configuration.Current.Helm = previous }()
+
+	manager := &helmPackageManager{}
+
+	configuration.Current.Helm.AppVersion = false
+	manager.SetVersion(semver.MustParse("1.3.0"), semver.MustParse("1.2.3"), false)
+
+	if manager.CurrentVersion() != "1.3.0" || !strings.Contains(readTextFile("Chart.yaml"), "appVersion: \"1.2.3\"\n") {
+		t.Errorf("unexpected chart:\n%s", readTextFile("Chart.yaml"))
+	}
+
+	configuration.Current.Helm.AppVersion = true
+	manager.SetVersion(semver.MustParse("1.4.0-rc.0"), semver.MustParse("1.3.0"), false)
+
+	expected := "apiVersion: v2\nname: app\nversion: 1.4.0-rc.0 # The chart version\nappVersion: \"1.4.0-rc.0\"\ndependencies:\n  - name: db\n    version: 1.0.0\n"
+	if actual := readTextFile("Chart.yaml"); actual != expected {
+		t.Errorf("unexpected chart:\n%s", actual)
+	}
+}
+
+func TestPushHelmChartToChartMuseum(t *testing.T) {
+	done := useTestFolder(t, map[string]string{"app-1.3.0.tgz": "chart archive"})
+	defer done()
+
+	uploaded := false
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contents, _ := ioutil.ReadAll(r.Body)
+
+		if r.Method != "POST" || r.URL.Path != "/api/charts" || r.Header.Get("Content-Type") != "application/gzip" {
+			http.NotFound(w, r)
+			return
+		}
+
+		if username, password, ok := r.BasicAuth(); !ok || username != "user" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		uploaded = string(contents) == "chart archive"
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"saved": true}`))
+	}))
+	defer server.Close()
+
+	previous := configuration.Current.Helm
+	configuration.Current.Helm.Repository = server.URL + "/"
+	defer func() { configuration.Current.Helm = previous }()
+
+	defer os.Unsetenv("IMPACCA_HELM_USERNAME")
+	defer os.Unsetenv("IMPACCA_HELM_PASSWORD")
+	os.Setenv("IMPACCA_HELM_USERNAME", "user")
+	os.Setenv("IMPACCA_HELM_PASSWORD", "secret")
+
+	cwd, _ := os.Getwd()
+	pushHelmChart(filepath.Join(cwd, "app-1.3.0.tgz"), false)
+
+	if !uploaded {
+		t.Error("the chart has not been uploaded")
+	}
+}
+
+func TestBuildContainerImage(t *testing.T) {
+	done := useTestFolder(t, map[string]string{
+		"Containerfile": "FROM scratch\n",
+		"bin/builder":   "#!/bin/sh\necho \"$@\" >> \"$IMPACCA_TEST_LOG\"\n",
+	})
+	defer done()
+
+	cwd, _ := os.Getwd()
+	os.Chmod(filepath.Join(cwd, "bin", "builder"), 0755)
+
+	defer os.Unsetenv("IMPACCA_TEST_LOG")
+	os.Setenv("IMPACCA_TEST_LOG", filepath.Join(cwd, "builder.log"))
+
+	previous := configuration.Current.Container
+	defer func() { configuration.Current.Container = previous }()
+
+	configuration.Current.Container.Image = "localhost:5000/app"
+	configuration.Current.Container.Dockerfile = "Containerfile"
+	configuration.Current.Container.Builder = filepath.Join(cwd, "bin", "builder")
+	configuration.Current.Container.Push = true
+
+	BuildContainerImage(semver.MustParse("1.3.0+build.5"), false)
+
+	expected := "build --tag localhost:5000/app:1.3.0_build.5 --file " + filepath.Join(cwd, "Containerfile") + " " + cwd + "\n" +
+		"push localhost:5000/app:1.3.0_build.5\n"
+
+	if actual := readTextFile("builder.log"); actual != expected {
+		t.Errorf("unexpected commands:\n%s", actual)
+	}
+}
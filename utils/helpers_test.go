@@ -0,0 +1,141 @@
+/*
+ * This file is part of impacca. Copyright (C) 2013 and above Shogun <shogun@cowtech.it>.
+ * Licensed under the MIT license, which can be found at https://choosealicense.com/licenses/mit.
+ */
+
+package utils
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ShogunPanda/impacca/configuration"
+)
+
+// useTestFolder switches to a new folder containing some files, by relative path.
+// The returned function switches back to the original folder and deletes the new one.
+func useTestFolder(t *testing.T, files map[string]string) func() {
+	t.Helper()
+
+	folder, err := ioutil.TempDir("", "impacca-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cwd, _ := os.Getwd()
+	os.Chdir(folder)
+	writeTestFiles(t, files)
+
+	return func() {
+		os.Chdir(cwd)
+		os.RemoveAll(folder)
+	}
+}
+
+// writeTestFiles writes files in the current folder, by relative path.
+func writeTestFiles(t *testing.T, files map[string]string) {
+	t.Helper()
+
+	for name, contents := range files {
+		os.MkdirAll(filepath.Dir(name), 0755)
+
+		if err := ioutil.WriteFile(name, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// readTestFiles reads files in the current folder, by relative path.
+func readTestFiles(t *testing.T, names ...string) map[string]string {
+	t.Helper()
+	files := make(map[string]string)
+
+	for _, name := range names {
+		contents, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		files[name] = string(contents)
+	}
+
+	return files
+}
+
+// useTestGitRepository switches to a new GIT repository with a commit having the given tags.
+// The returned function switches back to the original folder and deletes the repository.
+func useTestGitRepository(t *testing.T, tags ...string) func() {
+	t.Helper()
+
+	done := useTestFolder(t, map[string]string{})
+	commands := [][]string{
+		{"init", "--quiet"},
+		{"config", "user.name", "impacca"},
+		{"config", "user.email", "impacca@example.com"},
+		{"commit", "--quiet", "--allow-empty", "--message=Initial commit."},
+	}
+
+	for _, tag := range tags {
+		commands = append(commands, []string{"tag", tag})
+	}
+
+	for _, args := range commands {
+		if output, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			done()
+			t.Fatalf("git %v failed: %s", args, output)
+		}
+	}
+
+	return done
+}
+
+// runTestGit runs a GIT command in the current folder and returns its output.
+func runTestGit(t *testing.T, args ...string) string {
+	t.Helper()
+
+	output, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v failed: %s", args, output)
+	}
+
+	return strings.TrimSpace(string(output))
+}
+
+// expectFatal checks that a function exits with an error message, as Fatal terminates the process.
+// The current test is executed again in a child process, which runs the function.
+func expectFatal(t *testing.T, message string, fn func()) {
+	t.Helper()
+
+	if os.Getenv("IMPACCA_TEST_FATAL") == t.Name() {
+		fn()
+		os.Exit(0)
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^"+t.Name()+"$")
+	cmd.Env = append(os.Environ(), "IMPACCA_TEST_FATAL="+t.Name())
+	output, err := cmd.CombinedOutput()
+
+	if exitError, failed := err.(*exec.ExitError); !failed || exitError.Success() {
+		t.Errorf("expected a failure, got: %s", output)
+	} else if !strings.Contains(string(output), message) {
+		t.Errorf("expected the error %q, got: %s", message, output)
+	}
+}
+
+// useTestAPIServer starts a local server whose requests are served by a handler and returns its URL.
+// The returned function stops the server and restores the configuration changed by the test.
+func useTestAPIServer(handler http.HandlerFunc) (string, func()) {
+	server := httptest.NewServer(handler)
+	previous := configuration.Current
+
+	return server.URL, func() {
+		configuration.Current = previous
+		server.Close()
+	}
+}
@@ -0,0 +1,318 @@
+/*
+ * This file is part of impacca. Copyright (C) 2013 and above Shogun <shogun@cowtech.it>.
+ * Licensed under the MIT license, which can be found at https://choosealicense.com/licenses/mit.
+ */
+
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/ShogunPanda/impacca/configuration"
+)
+
+// snapshotSuffix is the suffix of JVM development versions
+const snapshotSuffix = "-SNAPSHOT"
+
+var xmlTokenMatcher = regexp.MustCompile("<!--[\\s\\S]*?-->|<!\\[CDATA\\[[\\s\\S]*?\\]\\]>|<[?!][\\s\\S]*?>|<(/?)([^\\s/>]+)[^>]*?(/?)>")
+var mavenModuleMatcher = regexp.MustCompile("<module>\\s*([^<]+?)\\s*</module>")
+var gradlePropertiesVersionMatcher = regexp.MustCompile("(?m)^(\\s*version\\s*[=:]\\s*)([^\\s#!]+)()")
+var gradleScriptVersionMatcher = regexp.MustCompile("(?m)^(\\s*(?:project\\.)?version\\s*=?\\s*[\"'])([^\"']+)([\"'])")
+
+type xmlElement struct {
+	name     string
+	start    int
+	children map[string][2]int
+}
+
+// mavenVersionRanges returns the positions of the version of a pom.xml, of its parent and of its dependencies,
+// if they are modules of the same project.
+func mavenVersionRanges(contents string, modules map[string]bool) ([][2]int, string) {
+	ranges := make([][2]int, 0)
+	artifact := ""
+	stack := make([]*xmlElement, 0)
+
+	for _, token := range xmlTokenMatcher.FindAllStringSubmatchIndex(contents, -1) {
+		// Comments, CDATA, declarations and processing instructions
+		if token[4] == -1 {
+			continue
+		}
+
+		name := contents[token[4]:token[5]]
+		closing := token[3] > token[2]
+		selfClosing := token[7] > token[6]
+
+		if !closing {
+			if !selfClosing {
+				stack = append(stack, &xmlElement{name: name, start: token[1], children: make(map[string][2]int)})
+			}
+
+			continue
+		}
+
+		if len(stack) == 0 || stack[len(stack)-1].name != name {
+			Fatal("Cannot parse the XML file: unexpected closing tag {errorPrimary}%s{-}.", name)
+		}
+
+		element := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if len(stack) > 0 {
+			stack[len(stack)-1].children[name] = [2]int{element.start, token[0]}
+		}
+
+		path := make([]string, 0, len(stack)+1)
+		for _, ancestor := range stack {
+			path = append(path, ancestor.name)
+		}
+
+		switch strings.Join(append(path, name), ".") {
+		case "project":
+			if position, found := element.children["artifactId"]; found {
+				artifact = strings.TrimSpace(contents[position[0]:position[1]])
+			}
+
+			if position, found := element.children["version"]; found {
+				ranges = append(ranges, position)
+			}
+		case "project.parent", "project.dependencies.dependency", "project.dependencyManagement.dependencies.dependency":
+			artifactPosition, hasArtifact := element.children["artifactId"]
+			versionPosition, hasVersion := element.children["version"]
+
+			// Versions using properties, like ${project.version}, are left untouched
+			if hasArtifact && hasVersion && modules[strings.TrimSpace(contents[artifactPosition[0]:artifactPosition[1]])] &&
+				!strings.Contains(contents[versionPosition[0]:versionPosition[1]], "${") {
+				ranges = append(ranges, versionPosition)
+			}
+		}
+	}
+
+	return ranges, artifact
+}
+
+// mavenPoms returns the pom.xml of a project and of all its modules, recursively.
+func mavenPoms(folder string) []string {
+	pom := filepath.Join(folder, "pom.xml")
+	poms := []string{pom}
+
+	for _, module := range mavenModuleMatcher.FindAllStringSubmatch(readTextFile(pom), -1) {
+		modulePath := filepath.Join(folder, module[1])
+
+		// Modules can either be folders or pom files
+		if strings.HasSuffix(module[1], ".xml") {
+			poms = append(poms, modulePath)
+		} else {
+			poms = append(poms, mavenPoms(modulePath)...)
+		}
+	}
+
+	return poms
+}
+
+// gradleVersionFiles returns the Gradle files which can contain the project version.
+func gradleVersionFiles(folder string) []string {
+	files := make([]string, 0)
+
+	for _, name := range []string{"gradle.properties", "build.gradle", "build.gradle.kts"} {
+		if _, err := os.Stat(filepath.Join(folder, name)); err == nil {
+			files = append(files, filepath.Join(folder, name))
+		}
+	}
+
+	return files
+}
+
+// gradleVersionMatcher returns the matcher of the project version in a Gradle file.
+func gradleVersionMatcher(file string) *regexp.Regexp {
+	if filepath.Base(file) == "gradle.properties" {
+		return gradlePropertiesVersionMatcher
+	}
+
+	return gradleScriptVersionMatcher
+}
+
+// jvmPackageManager releases JVM projects using either Maven or Gradle
+type jvmPackageManager struct {
+	changedFiles
+	maven     bool
+	snapshots bool
+}
+
+// versionChanges returns the current contents of all files containing the project version and the contents after the update.
+// When version is empty, the files are not updated.
+func (m *jvmPackageManager) versionChanges(folder, version string) ([]string, map[string][2]string) {
+	files := make([]string, 0)
+	changes := make(map[string][2]string)
+
+	if m.maven {
+		poms := mavenPoms(folder)
+		modules := make(map[string]bool)
+
+		for _, pom := range poms {
+			_, artifact := mavenVersionRanges(readTextFile(pom), modules)
+			modules[artifact] = true
+		}
+
+		for _, pom := range poms {
+			contents := readTextFile(pom)
+			updated := contents
+			ranges, _ := mavenVersionRanges(contents, modules)
+
+			// Replace from the end so that positions are still valid
+			for i := len(ranges) - 1; i >= 0 && version != ""; i-- {
+				updated = updated[:ranges[i][0]] + version + updated[ranges[i][1]:]
+			}
+
+			files = append(files, pom)
+			changes[pom] = [2]string{contents, updated}
+		}
+
+		return files, changes
+	}
+
+	for _, file := range gradleVersionFiles(folder) {
+		contents := readTextFile(file)
+		matcher := gradleVersionMatcher(file)
+
+		if !matcher.MatchString(contents) {
+			continue
+		}
+
+		updated := contents
+		if version != "" {
+			updated = matcher.ReplaceAllString(contents, "${1}"+version+"${3}")
+		}
+
+		files = append(files, file)
+		changes[file] = [2]string{contents, updated}
+	}
+
+	return files, changes
+}
+
+// setVersion updates the version of the Maven or Gradle project in the current folder.
+func (m *jvmPackageManager) setVersion(version string, dryRun bool) {
+	cwd, _ := os.Getwd()
+	files, changes := m.versionChanges(cwd, version)
+	m.resetFiles()
+
+	for _, file := range files {
+		relative, _ := filepath.Rel(cwd, file)
+
+		if changes[file][0] != changes[file][1] {
+			writeUpdatedFile(file, relative, changes[file][0], changes[file][1], dryRun)
+			m.trackFile(file)
+		}
+	}
+
+	if len(m.Files()) == 0 && m.CurrentVersion() != version {
+		Fatal("Cannot find the project version in {errorPrimary}pom.xml{-}, {errorPrimary}gradle.properties{-} or {errorPrimary}build.gradle{-}.")
+	}
+}
+
+// buildTool returns the Maven or Gradle executable of the project, preferring wrappers.
+func (m *jvmPackageManager) buildTool() string {
+	cwd, _ := os.Getwd()
+	wrapper, tool := "gradlew", "gradle"
+
+	if m.maven {
+		wrapper, tool = "mvnw", "mvn"
+	}
+
+	if stat, err := os.Stat(filepath.Join(cwd, wrapper)); err == nil && stat.Mode()&0111 != 0 {
+		return "./" + wrapper
+	}
+
+	return tool
+}
+
+func (m *jvmPackageManager) Name() string {
+	if m.maven {
+		return "maven"
+	}
+
+	return "gradle"
+}
+
+func (m *jvmPackageManager) Detect(folder string) bool {
+	if m.maven {
+		_, err := os.Stat(filepath.Join(folder, "pom.xml"))
+		return err == nil
+	}
+
+	return len(gradleVersionFiles(folder)) > 0
+}
+
+// CurrentVersion returns the version of the Maven or Gradle project in the current folder.
+func (m *jvmPackageManager) CurrentVersion() string {
+	cwd, _ := os.Getwd()
+	files, changes := m.versionChanges(cwd, "")
+
+	for _, file := range files {
+		contents := changes[file][0]
+
+		if m.maven {
+			if ranges, _ := mavenVersionRanges(contents, map[string]bool{}); len(ranges) > 0 {
+				return strings.TrimSpace(contents[ranges[0][0]:ranges[0][1]])
+			}
+		} else if matches := gradleVersionMatcher(file).FindStringSubmatch(contents); matches != nil {
+			return matches[2]
+		}
+	}
+
+	return ""
+}
+
+// SetVersion updates the version of a Maven or Gradle project.
+// Projects using snapshots will go back to a development version after the release.
+func (m *jvmPackageManager) SetVersion(newVersion, currentVersion *semver.Version, dryRun bool) {
+	m.snapshots = strings.HasSuffix(m.CurrentVersion(), snapshotSuffix)
+	m.setVersion(FormatVersion(newVersion), dryRun)
+}
+
+// PrepareDevelopmentVersion updates the version of the project to the snapshot following a release.
+// Prereleases are followed by the snapshot of their stable version, while other versions are followed by the snapshot of the next patch.
+func (m *jvmPackageManager) PrepareDevelopmentVersion(releasedVersion *semver.Version, dryRun bool) {
+	if !m.snapshots {
+		return
+	}
+
+	stable, _ := releasedVersion.SetPrerelease("")
+	stable, _ = stable.SetMetadata("")
+	nextVersion := &stable
+
+	if releasedVersion.Prerelease() == "" {
+		nextVersion = CurrentVersioningScheme().Increment(&stable, "patch")
+	}
+
+	snapshot := FormatVersion(nextVersion) + snapshotSuffix
+	message := strings.TrimSpace(fmt.Sprintf(configuration.Current.CommitMessages.Snapshot, snapshot))
+
+	NotifyStep(dryRun, "", "Will prepare", "Preparing", " the development version {primary}%s{-} ...", snapshot)
+	m.setVersion(snapshot, dryRun)
+
+	if NotifyExecution(dryRun, "Will execute", "Executing", ": {primary}git commit --all --message=\"%s\"{-} ...", message) {
+		result := Execute(true, "git", "commit", "--all", fmt.Sprintf("--message=%s", message))
+		result.Verify("git", "Cannot commit development version change")
+	}
+}
+
+// Publish deploys the release version, before going back to a development version.
+func (m *jvmPackageManager) Publish(version *semver.Version, options PublishOptions) {
+	tool := m.buildTool()
+	task := "publish"
+
+	if m.maven {
+		task = "deploy"
+	}
+
+	if NotifyExecution(options.DryRun, "Will execute", "Executing", ": {primary}%s %s{-} ...", tool, task) {
+		result := Execute(true, tool, task)
+		result.Verify(tool, "Cannot deploy the project")
+	}
+}
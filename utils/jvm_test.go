@@ -0,0 +1,182 @@
+/*
+ * This file is part of impacca. Copyright (C) 2013 and above Shogun <shogun@cowtech.it>.
+ * Licensed under the MIT license, which can be found at https://choosealicense.com/licenses/mit.
+ */
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/Masterminds/semver"
+	"github.com/ShogunPanda/impacca/configuration"
+)
+
+const testRootPom = `<?xml version="1.0" encoding="UTF-8"?>
+<!-- <version>0.0.0</version> -->
+<project>
+  <parent>
+    <groupId>org.springframework.boot</groupId>
+    <artifactId>spring-boot-starter-parent</artifactId>
+    <version>3.1.0</version>
+  </parent>
+  <artifactId>app</artifactId>
+  <version>1.2.3-SNAPSHOT</version>
+  <modules>
+    <module>core</module>
+  </modules>
+  <dependencies>
+    <dependency>
+      <artifactId>junit</artifactId>
+      <version>4.13</version>
+    </dependency>
+  </dependencies>
+</project>
+`
+
+const testModulePom = `<project>
+  <parent>
+    <artifactId>app</artifactId>
+    <version>1.2.3-SNAPSHOT</version>
+  </parent>
+  <artifactId>app-core</artifactId>
+  <dependencies>
+    <dependency>
+      <artifactId>app</artifactId>
+      <version>${project.version}</version>
+    </dependency>
+  </dependencies>
+</project>
+`
+
+func TestJVMSetVersion(t *testing.T) {
+	cases := []struct {
+		name     string
+		files    map[string]string
+		current  string
+		expected map[string]string
+	}{
+		{
+			"maven",
+			map[string]string{"pom.xml": testRootPom, "core/pom.xml": testModulePom},
+			"1.2.3-SNAPSHOT",
+			map[string]string{
+				"pom.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<!-- <version>0.0.0</version> -->
+<project>
+  <parent>
+    <groupId>org.springframework.boot</groupId>
+    <artifactId>spring-boot-starter-parent</artifactId>
+    <version>3.1.0</version>
+  </parent>
+  <artifactId>app</artifactId>
+  <version>1.2.3</version>
+  <modules>
+    <module>core</module>
+  </modules>
+  <dependencies>
+    <dependency>
+      <artifactId>junit</artifactId>
+      <version>4.13</version>
+    </dependency>
+  </dependencies>
+</project>
+`,
+				"core/pom.xml": `<project>
+  <parent>
+    <artifactId>app</artifactId>
+    <version>1.2.3</version>
+  </parent>
+  <artifactId>app-core</artifactId>
+  <dependencies>
+    <dependency>
+      <artifactId>app</artifactId>
+      <version>${project.version}</version>
+    </dependency>
+  </dependencies>
+</project>
+`,
+			},
+		},
+		{
+			"gradle properties",
+			map[string]string{"gradle.properties": "group=org.example\nversion = 1.2.3-SNAPSHOT # Current\n", "build.gradle": "plugins {}\n"},
+			"1.2.3-SNAPSHOT",
+			map[string]string{"gradle.properties": "group=org.example\nversion = 1.2.3 # Current\n", "build.gradle": "plugins {}\n"},
+		},
+		{
+			"gradle script",
+			map[string]string{"build.gradle": "group 'org.example'\nversion '1.2.3-SNAPSHOT'\n"},
+			"1.2.3-SNAPSHOT",
+			map[string]string{"build.gradle": "group 'org.example'\nversion '1.2.3'\n"},
+		},
+		{
+			"gradle kotlin script",
+			map[string]string{"build.gradle.kts": "group = \"org.example\"\nproject.version = \"1.2.2\"\n"},
+			"1.2.2",
+			map[string]string{"build.gradle.kts": "group = \"org.example\"\nproject.version = \"1.2.3\"\n"},
+		},
+	}
+
+	for _, tc := range cases {
+		done := useTestFolder(t, tc.files)
+		manager := DetectPackageManager()
+
+		if actual := manager.CurrentVersion(); actual != tc.current {
+			t.Errorf("%s: expected current version %s, got %s", tc.name, tc.current, actual)
+		}
+
+		manager.SetVersion(semver.MustParse("1.2.3"), semver.MustParse(tc.current), false)
+
+		for file, expected := range tc.expected {
+			if actual := readTextFile(file); actual != expected {
+				t.Errorf("%s: unexpected contents of %s:\n%s", tc.name, file, actual)
+			}
+		}
+
+		done()
+	}
+}
+
+func TestUpdateVersionPreparesJVMSnapshot(t *testing.T) {
+	previousVersionFiles := configuration.Current.VersionFiles
+	configuration.Current.VersionFiles = []configuration.VersionFile{}
+	defer func() { configuration.Current.VersionFiles = previousVersionFiles }()
+
+	cases := []struct {
+		current  string
+		version  string
+		expected string
+	}{
+		{"1.2.3-SNAPSHOT", "1.2.3", "1.2.4-SNAPSHOT"},
+		{"1.3.0-SNAPSHOT", "1.3.0-beta.0", "1.3.0-SNAPSHOT"},
+		{"1.2.3", "1.2.4", "1.2.4"},
+	}
+
+	for _, tc := range cases {
+		done := useTestGitRepository(t)
+		writeTestFiles(t, map[string]string{"gradle.properties": "version=" + tc.current + "\n"})
+		runTestGit(t, "add", "--all")
+		runTestGit(t, "commit", "--quiet", "--message=Add project.")
+
+		UpdateVersion(semver.MustParse(tc.version), semver.MustParse("1.2.3"), false)
+
+		if actual := DetectPackageManager().CurrentVersion(); actual != tc.expected {
+			t.Errorf("%s: expected version %s, got %s", tc.version, tc.expected, actual)
+		}
+
+		if actual := runTestGit(t, "show", "--no-patch", "--format=%s", "v"+tc.version); actual != "Version "+tc.version+"." {
+			t.Errorf("%s: unexpected tagged commit %s", tc.version, actual)
+		}
+
+		if tc.expected != tc.version && runTestGit(t, "log", "-1", "--format=%s") != "Prepare for version "+tc.expected+"." {
+			t.Errorf("%s: the development version has not been committed", tc.version)
+		}
+
+		if status := runTestGit(t, "status", "--short"); status != "" {
+			t.Errorf("%s: unexpected uncommitted changes %s", tc.version, status)
+		}
+
+		done()
+	}
+}
@@ -0,0 +1,356 @@
+/*
+ * This file is part of impacca. Copyright (C) 2013 and above Shogun <shogun@cowtech.it>.
+ * Licensed under the MIT license, which can be found at https://choosealicense.com/licenses/mit.
+ */
+
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver"
+	"github.com/ShogunPanda/impacca/configuration"
+)
+
+// See https://keepachangelog.com/en/1.0.0/
+const keepAChangelogPreamble = `# Changelog
+
+All notable changes to this project will be documented in this file.
+
+The format is based on [Keep a Changelog](https://keepachangelog.com/en/1.0.0/),
+and this project adheres to [Semantic Versioning](https://semver.org/spec/v2.0.0.html).`
+
+const keepAChangelogUnreleased = "Unreleased"
+
+var keepAChangelogCategories = []string{"Added", "Changed", "Deprecated", "Removed", "Fixed", "Security"}
+var keepAChangelogVersionMatcher = regexp.MustCompile("^##\\s+\\[?([^\\]\\s]+)\\]?(?:\\s+-\\s+(\\d{4}-\\d{2}-\\d{2}))?(.*)$")
+var keepAChangelogCategoryMatcher = regexp.MustCompile("^###\\s+(.+)$")
+var keepAChangelogEntryMatcher = regexp.MustCompile("^[-*]\\s+(.*)$")
+var keepAChangelogLinkMatcher = regexp.MustCompile("^\\[([^\\]]+)\\]:\\s*(\\S+)\\s*$")
+
+// VersionChanges represents all the changes of a version
+type VersionChanges struct {
+	Version         *semver.Version
+	PreviousVersion *semver.Version
+	Date            time.Time
+	Changes         []Change
+}
+
+type keepAChangelogCategory struct {
+	Title   string
+	Entries []string
+}
+
+type keepAChangelogSection struct {
+	Version    string
+	Date       string
+	Suffix     string
+	Notes      string
+	Categories []*keepAChangelogCategory
+}
+
+type keepAChangelog struct {
+	Preamble   string
+	Unreleased *keepAChangelogSection
+	Versions   []*keepAChangelogSection
+	Links      [][2]string
+}
+
+// IsKeepAChangelog checks if CHANGELOG.md uses the Keep a Changelog format.
+func IsKeepAChangelog() bool {
+	return strings.EqualFold(configuration.Current.Changelog.Format, "keepachangelog")
+}
+
+func (s *keepAChangelogSection) category(title string) *keepAChangelogCategory {
+	for _, category := range s.Categories {
+		if strings.EqualFold(category.Title, title) {
+			return category
+		}
+	}
+
+	category := &keepAChangelogCategory{Title: title, Entries: make([]string, 0)}
+	s.Categories = append(s.Categories, category)
+	return category
+}
+
+func (s *keepAChangelogSection) addEntry(title, entry string) {
+	category := s.category(title)
+
+	// Do not duplicate entries
+	for _, existing := range category.Entries {
+		if existing == entry {
+			return
+		}
+	}
+
+	category.Entries = append(category.Entries, entry)
+}
+
+func (s *keepAChangelogSection) String() string {
+	var builder strings.Builder
+
+	// Versions without a date, like the unreleased one, have no separator
+	if s.Version == keepAChangelogUnreleased || s.Date == "" {
+		builder.WriteString(fmt.Sprintf("## [%s]%s\n\n", s.Version, s.Suffix))
+	} else {
+		builder.WriteString(fmt.Sprintf("## [%s] - %s%s\n\n", s.Version, s.Date, s.Suffix))
+	}
+
+	if notes := strings.TrimSpace(s.Notes); notes != "" {
+		builder.WriteString(notes + "\n\n")
+	}
+
+	// Standard categories come first, in the standard order
+	categories := make([]*keepAChangelogCategory, 0)
+	for _, title := range keepAChangelogCategories {
+		for _, category := range s.Categories {
+			if strings.EqualFold(category.Title, title) {
+				categories = append(categories, category)
+			}
+		}
+	}
+
+	for _, category := range s.Categories {
+		standard := false
+
+		for _, title := range keepAChangelogCategories {
+			standard = standard || strings.EqualFold(category.Title, title)
+		}
+
+		if !standard {
+			categories = append(categories, category)
+		}
+	}
+
+	for _, category := range categories {
+		if len(category.Entries) == 0 {
+			continue
+		}
+
+		builder.WriteString(fmt.Sprintf("### %s\n\n", category.Title))
+
+		for _, entry := range category.Entries {
+			builder.WriteString(fmt.Sprintf("- %s\n", entry))
+		}
+
+		builder.WriteString("\n")
+	}
+
+	return builder.String()
+}
+
+func parseKeepAChangelog(contents string) *keepAChangelog {
+	changelog := &keepAChangelog{
+		Unreleased: &keepAChangelogSection{Version: keepAChangelogUnreleased},
+		Versions:   make([]*keepAChangelogSection, 0),
+		Links:      make([][2]string, 0),
+	}
+
+	var preamble strings.Builder
+	var section *keepAChangelogSection
+	var category *keepAChangelogCategory
+
+	for _, line := range strings.Split(strings.Replace(contents, "\r\n", "\n", -1), "\n") {
+		if matches := keepAChangelogLinkMatcher.FindStringSubmatch(line); matches != nil {
+			changelog.Links = append(changelog.Links, [2]string{matches[1], matches[2]})
+			continue
+		}
+
+		if matches := keepAChangelogVersionMatcher.FindStringSubmatch(line); matches != nil {
+			category = nil
+
+			if strings.EqualFold(matches[1], keepAChangelogUnreleased) {
+				section = changelog.Unreleased
+			} else {
+				section = &keepAChangelogSection{Version: matches[1], Date: matches[2]}
+				changelog.Versions = append(changelog.Versions, section)
+			}
+
+			section.Suffix = strings.TrimRight(matches[3], " \t")
+			continue
+		}
+
+		if section == nil {
+			preamble.WriteString(line + "\n")
+			continue
+		}
+
+		if matches := keepAChangelogCategoryMatcher.FindStringSubmatch(line); matches != nil {
+			category = section.category(strings.TrimSpace(matches[1]))
+			continue
+		}
+
+		if category == nil {
+			section.Notes += line + "\n"
+		} else if matches := keepAChangelogEntryMatcher.FindStringSubmatch(line); matches != nil {
+			category.Entries = append(category.Entries, matches[1])
+		} else if strings.TrimSpace(line) != "" && len(category.Entries) > 0 {
+			// Continuation of the previous entry
+			category.Entries[len(category.Entries)-1] += "\n" + line
+		}
+	}
+
+	changelog.Preamble = strings.TrimSpace(preamble.String())
+
+	if changelog.Preamble == "" {
+		changelog.Preamble = keepAChangelogPreamble
+	}
+
+	return changelog
+}
+
+func (c *keepAChangelog) String(repository *Repository) string {
+	var builder strings.Builder
+
+	builder.WriteString(c.Preamble + "\n\n")
+	builder.WriteString(c.Unreleased.String())
+
+	for _, version := range c.Versions {
+		builder.WriteString(version.String())
+	}
+
+	// Regenerate links for versions, keeping all other links
+	links := make([][2]string, 0)
+	generated := make(map[string]bool)
+
+	if repository != nil {
+		if len(c.Versions) > 0 {
+			links = append(
+				links,
+				[2]string{
+					keepAChangelogUnreleased, repository.CompareURL(VersionTag(c.Versions[0].Version), "HEAD"),
+				},
+			)
+		}
+
+		generated[strings.ToLower(keepAChangelogUnreleased)] = true
+
+		for i, version := range c.Versions {
+			url := repository.ReleaseURL(VersionTag(version.Version))
+
+			if i < len(c.Versions)-1 {
+				url = repository.CompareURL(VersionTag(c.Versions[i+1].Version), VersionTag(version.Version))
+			}
+
+			links = append(links, [2]string{version.Version, url})
+			generated[strings.ToLower(version.Version)] = true
+		}
+	}
+
+	for _, link := range c.Links {
+		if !generated[strings.ToLower(link[0])] {
+			links = append(links, link)
+		}
+	}
+
+	for _, link := range links {
+		builder.WriteString(fmt.Sprintf("[%s]: %s\n", link[0], link[1]))
+	}
+
+	return strings.TrimSpace(builder.String()) + "\n"
+}
+
+// addChanges adds changes to a section using the configured categories.
+func (c *keepAChangelog) addChanges(section *keepAChangelogSection, changes []Change) {
+	categories := configuration.Current.Changelog.KeepAChangelogCategories
+
+	for _, change := range changes {
+		if filterCommit(change) || isHiddenChange(change) {
+			continue
+		}
+
+		category, found := categories[change.Type]
+
+		if !found || category == "" {
+			category = "Changed"
+		}
+
+		entry := FormatChangeEntry(change, true)
+
+		if change.Breaking {
+			entry = "**BREAKING:** " + entry
+		}
+
+		section.addEntry(category, entry)
+	}
+}
+
+// release moves all unreleased entries in a new version section, adding changes.
+func (c *keepAChangelog) release(version *semver.Version, date time.Time, changes []Change, includeUnreleased bool) {
+	section := &keepAChangelogSection{Version: FormatVersion(version), Date: date.Format("2006-01-02")}
+
+	if includeUnreleased {
+		section.Notes = c.Unreleased.Notes
+		section.Categories = c.Unreleased.Categories
+		c.Unreleased.Notes = ""
+		c.Unreleased.Categories = nil
+	}
+
+	c.addChanges(section, changes)
+
+	// Replace an existing version
+	for i, existing := range c.Versions {
+		if existing.Version == section.Version {
+			c.Versions[i] = section
+			return
+		}
+	}
+
+	c.Versions = append([]*keepAChangelogSection{section}, c.Versions...)
+}
+
+// FormatKeepAChangelog releases a new version in a CHANGELOG.md using the Keep a Changelog format.
+func FormatKeepAChangelog(previous string, version *semver.Version, changes []Change, date time.Time) string {
+	changelog := parseKeepAChangelog(previous)
+	changelog.release(version, date, changes, true)
+
+	return changelog.String(detectChangelogRepository())
+}
+
+// FormatKeepAChangelogUnreleased adds changes to the Unreleased section of a CHANGELOG.md using the Keep a Changelog format.
+func FormatKeepAChangelogUnreleased(previous string, changes []Change) string {
+	changelog := parseKeepAChangelog(previous)
+	changelog.addChanges(changelog.Unreleased, changes)
+
+	return changelog.String(detectChangelogRepository())
+}
+
+// RegenerateKeepAChangelog regenerates versions of a CHANGELOG.md using the Keep a Changelog format.
+// The preamble, the Unreleased section and the links not related to versions are preserved.
+// Unless forced, existing versions sections are preserved too.
+func RegenerateKeepAChangelog(previous string, versions []VersionChanges, force bool) string {
+	changelog := parseKeepAChangelog(previous)
+
+	if force {
+		changelog.Versions = make([]*keepAChangelogSection, 0)
+	}
+
+	existing := make(map[string]bool)
+	for _, section := range changelog.Versions {
+		existing[section.Version] = true
+	}
+
+	for _, version := range versions {
+		if !existing[FormatVersion(version.Version)] {
+			changelog.release(version.Version, version.Date, version.Changes, false)
+		}
+	}
+
+	// Sort versions descending, keeping unparsable ones at the end
+	sort.SliceStable(changelog.Versions, func(i, j int) bool {
+		first, firstErr := ParseVersion(changelog.Versions[i].Version)
+		second, secondErr := ParseVersion(changelog.Versions[j].Version)
+
+		if firstErr != nil || secondErr != nil {
+			return firstErr == nil && secondErr != nil
+		}
+
+		return first.GreaterThan(second)
+	})
+
+	return changelog.String(detectChangelogRepository())
+}
@@ -0,0 +1,199 @@
+/*
+ * This file is part of impacca. Copyright (C) 2013 and above Shogun <shogun@cowtech.it>.
+ * Licensed under the MIT license, which can be found at https://choosealicense.com/licenses/mit.
+ */
+
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/semver"
+	"github.com/ShogunPanda/impacca/configuration"
+)
+
+const testKeepAChangelog = `# Changelog
+
+All notable changes to this project will be documented in this file.
+
+## [Unreleased]
+
+Upgrade notes.
+
+### Added
+
+- Arrays support
+
+## [1.2.0] - 2019-01-02
+
+### Custom
+
+- Something else
+
+### Fixed
+
+- Crash on empty input
+  with a continuation line
+
+## [1.1.0] - 2018-12-01 [YANKED]
+
+### Added
+
+- Objects support
+
+[Unreleased]: https://example.com/compare/v1.2.0...HEAD
+[1.2.0]: https://example.com/compare/v1.1.0...v1.2.0
+[docs]: https://example.com/docs
+`
+
+func TestParseKeepAChangelog(t *testing.T) {
+	changelog := parseKeepAChangelog(testKeepAChangelog)
+
+	if changelog.Preamble != "# Changelog\n\nAll notable changes to this project will be documented in this file." {
+		t.Errorf("unexpected preamble: %q", changelog.Preamble)
+	}
+
+	if len(changelog.Versions) != 2 || changelog.Versions[0].Version != "1.2.0" || changelog.Versions[1].Suffix != " [YANKED]" {
+		t.Fatalf("unexpected versions: %+v", changelog.Versions)
+	}
+
+	if entries := changelog.Versions[0].category("Fixed").Entries; len(entries) != 1 || entries[0] != "Crash on empty input\n  with a continuation line" {
+		t.Errorf("unexpected entries: %q", entries)
+	}
+
+	if len(changelog.Links) != 3 || changelog.Links[2] != [2]string{"docs", "https://example.com/docs"} {
+		t.Errorf("unexpected links: %v", changelog.Links)
+	}
+
+	expected := `# Changelog
+
+All notable changes to this project will be documented in this file.
+
+## [Unreleased]
+
+Upgrade notes.
+
+### Added
+
+- Arrays support
+
+## [1.2.0] - 2019-01-02
+
+### Fixed
+
+- Crash on empty input
+  with a continuation line
+
+### Custom
+
+- Something else
+
+## [1.1.0] - 2018-12-01 [YANKED]
+
+### Added
+
+- Objects support
+
+[Unreleased]: https://github.com/owner/repo/compare/v1.2.0...HEAD
+[1.2.0]: https://github.com/owner/repo/compare/v1.1.0...v1.2.0
+[1.1.0]: https://github.com/owner/repo/releases/tag/v1.1.0
+[docs]: https://example.com/docs
+`
+
+	if actual := changelog.String(testGitHubRepository); actual != expected {
+		t.Errorf("unexpected changelog:\n%s", actual)
+	}
+
+	// Without a repository, links are not regenerated
+	links := "[Unreleased]: https://example.com/compare/v1.2.0...HEAD\n[1.2.0]: https://example.com/compare/v1.1.0...v1.2.0\n[docs]: https://example.com/docs\n"
+
+	if actual := changelog.String(nil); !strings.HasSuffix(actual, "- Objects support\n\n"+links) {
+		t.Errorf("unexpected changelog:\n%s", actual)
+	}
+}
+
+func TestFormatKeepAChangelog(t *testing.T) {
+	defer useTestChangelogRepository(testGitHubRepository)()
+
+	changes := parseTestChanges("feat(lexer): Objects", "fix: Crash", "chore!: Drop Node 8", "Updated CHANGELOG.md.", "fix: Crash")
+	date := time.Date(2019, 2, 3, 0, 0, 0, 0, time.UTC)
+
+	expected := `# Changelog
+
+All notable changes to this project will be documented in this file.
+
+## [Unreleased]
+
+## [1.3.0] - 2019-02-03
+
+Upgrade notes.
+
+### Added
+
+- Arrays support
+- lexer: Objects
+
+### Changed
+
+- **BREAKING:** Drop Node 8
+
+### Fixed
+
+- Crash
+
+## [1.2.0] - 2019-01-02
+`
+
+	actual := FormatKeepAChangelog(testKeepAChangelog, semver.MustParse("1.3.0"), changes, date)
+
+	if actual[:len(expected)] != expected {
+		t.Errorf("unexpected changelog:\n%s", actual)
+	}
+
+	previous := configuration.Current.Changelog
+	configuration.Current.Changelog.HiddenTypes = []string{"fix"}
+	defer func() { configuration.Current.Changelog = previous }()
+
+	expected = keepAChangelogPreamble + `
+
+## [Unreleased]
+
+### Added
+
+- lexer: Objects
+
+### Changed
+
+- **BREAKING:** Drop Node 8
+`
+
+	actual = FormatKeepAChangelogUnreleased("", changes)
+
+	if actual != expected {
+		t.Errorf("unexpected changelog:\n%s", actual)
+	}
+}
+
+func TestKeepAChangelogSectionHeadings(t *testing.T) {
+	cases := []struct {
+		heading  string
+		expected string
+	}{
+		{"## [Unreleased]", "## [Unreleased]"},
+		{"## [1.0.0] - 2019-01-02", "## [1.0.0] - 2019-01-02"},
+		{"## [1.0.0] - 2019-01-02 [YANKED]", "## [1.0.0] - 2019-01-02 [YANKED]"},
+		{"## [1.0.0]", "## [1.0.0]"},
+		{"## 1.0.0", "## [1.0.0]"},
+		{"## [1.0.0] [YANKED]", "## [1.0.0] [YANKED]"},
+	}
+
+	for _, tc := range cases {
+		changelog := parseKeepAChangelog(keepAChangelogPreamble + "\n\n" + tc.heading + "\n\n### Added\n\n- Something\n")
+
+		if actual := changelog.String(nil); !strings.Contains(actual, "\n"+tc.expected+"\n\n### Added") {
+			t.Errorf("%s: unexpected changelog:\n%s", tc.heading, actual)
+		}
+	}
+}
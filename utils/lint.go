@@ -0,0 +1,181 @@
+/*
+ * This file is part of impacca. Copyright (C) 2013 and above Shogun <shogun@cowtech.it>.
+ * Licensed under the MIT license, which can be found at https://choosealicense.com/licenses/mit.
+ */
+
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/ShogunPanda/impacca/configuration"
+)
+
+var lintCommitMatcher = regexp.MustCompile("(?:/commit/|\\[)([0-9a-f]{7,40})\\b")
+
+// LintProblem represents a problem found in the CHANGELOG.md file
+type LintProblem struct {
+	Rule    string `json:"rule"`
+	Version string `json:"version,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Message string `json:"message"`
+}
+
+// expectedChangelogHeading returns a matcher for the versions headings, if the format is known.
+func expectedChangelogHeading(version string) *regexp.Regexp {
+	quoted := regexp.QuoteMeta(version)
+	settings := configuration.Current.Templates.Changelog
+
+	if IsKeepAChangelog() {
+		if version == "" {
+			return regexp.MustCompile("^## \\[Unreleased\\]$")
+		}
+
+		return regexp.MustCompile(fmt.Sprintf("^## \\[%s\\] - \\d{4}-\\d{2}-\\d{2}(?: \\[YANKED\\])?$", quoted))
+	} else if version == "" || settings.Inline != "" || settings.File != "" {
+		return nil
+	}
+
+	switch settings.Preset {
+	case "", "impacca":
+		return regexp.MustCompile(fmt.Sprintf("^### \\d{4}-\\d{2}-\\d{2} / %s$", quoted))
+	case "conventional":
+		return regexp.MustCompile(fmt.Sprintf("^## (?:\\[%[1]s\\]\\(\\S+\\)|%[1]s)(?: \\(\\d{4}-\\d{2}-\\d{2}\\))?$", quoted))
+	case "minimal":
+		return regexp.MustCompile(fmt.Sprintf("^## %s$", quoted))
+	}
+
+	return nil
+}
+
+// changelogHasDates checks if the versions headings are expected to contain the version date.
+func changelogHasDates() bool {
+	settings := configuration.Current.Templates.Changelog
+
+	return IsKeepAChangelog() || (settings.Inline == "" && settings.File == "" && settings.Preset != "minimal")
+}
+
+func commitExists(hash string, cache map[string]bool) bool {
+	if exists, found := cache[hash]; found {
+		return exists
+	}
+
+	result := Execute(false, "git", "cat-file", "-e", fmt.Sprintf("%s^{commit}", hash))
+	cache[hash] = result.Error == nil && result.ExitCode == 0
+
+	return cache[hash]
+}
+
+// LintChangelog validates a CHANGELOG.md file against the GIT versions.
+func LintChangelog(changelog *Changelog, versions semver.Collection) []LintProblem {
+	problems := make([]LintProblem, 0)
+	seen := make(map[string]int)
+	commits := make(map[string]bool)
+	var previous *semver.Version
+
+	for _, section := range changelog.Sections {
+		header := strings.TrimSpace(section.Header)
+
+		// Check the heading format
+		if matcher := expectedChangelogHeading(section.Version); matcher != nil && !matcher.MatchString(header) {
+			problems = append(problems, LintProblem{
+				Rule: "heading-format", Version: section.Version, Line: section.Line,
+				Message: fmt.Sprintf("The heading \"%s\" does not follow the configured format.", header),
+			})
+		}
+
+		// Check that entries reference existing commits
+		for _, entry := range section.Entries {
+			// Links usually contain the commit twice, in the text and in the URL
+			reported := make(map[string]bool)
+
+			for _, matches := range lintCommitMatcher.FindAllStringSubmatch(entry.Text, -1) {
+				if !reported[matches[1]] && !commitExists(matches[1], commits) {
+					reported[matches[1]] = true
+					problems = append(problems, LintProblem{
+						Rule: "unknown-commit", Version: section.Version, Line: section.Line,
+						Message: fmt.Sprintf("The entry \"%s\" references the commit %s which does not exist.", entry.Text, matches[1]),
+					})
+				}
+			}
+		}
+
+		if section.Unreleased {
+			continue
+		}
+
+		// Check duplicates
+		current, err := ParseVersion(section.Version)
+		key := section.Version
+
+		// Versions are compared in the configured format, ignoring the heading format
+		if err == nil {
+			key = FormatVersion(current)
+		}
+
+		if line, duplicate := seen[key]; duplicate {
+			problems = append(problems, LintProblem{
+				Rule: "duplicate-version", Version: section.Version, Line: section.Line,
+				Message: fmt.Sprintf("The version %s is already present at line %d.", section.Version, line),
+			})
+
+			continue
+		}
+
+		seen[key] = section.Line
+
+		// Check ordering
+		if err != nil {
+			problems = append(problems, LintProblem{
+				Rule: "invalid-version", Version: section.Version, Line: section.Line,
+				Message: fmt.Sprintf("The version %s is not a valid version.", section.Version),
+			})
+
+			continue
+		}
+
+		if previous != nil && !current.LessThan(previous) {
+			problems = append(problems, LintProblem{
+				Rule: "version-order", Version: section.Version, Line: section.Line,
+				Message: fmt.Sprintf("The version %s should come before version %s.", section.Version, FormatVersion(previous)),
+			})
+		}
+
+		previous = current
+
+		// Check the date
+		if section.Date == "" && !changelogHasDates() {
+			continue
+		}
+
+		for _, version := range versions {
+			if !version.Equal(current) {
+				continue
+			}
+
+			expected := GetVersionDate(version).Format("2006-01-02")
+
+			if section.Date != expected {
+				problems = append(problems, LintProblem{
+					Rule: "version-date", Version: section.Version, Line: section.Line,
+					Message: fmt.Sprintf("The version %s has date \"%s\" instead of \"%s\".", section.Version, section.Date, expected),
+				})
+			}
+		}
+	}
+
+	// Check that all versions are present
+	for _, version := range versions {
+		if _, found := seen[FormatVersion(version)]; !found {
+			problems = append(problems, LintProblem{
+				Rule: "missing-version", Version: FormatVersion(version),
+				Message: fmt.Sprintf("The version %s is not present.", FormatVersion(version)),
+			})
+		}
+	}
+
+	return problems
+}
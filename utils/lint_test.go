@@ -0,0 +1,92 @@
+/*
+ * This file is part of impacca. Copyright (C) 2013 and above Shogun <shogun@cowtech.it>.
+ * Licensed under the MIT license, which can be found at https://choosealicense.com/licenses/mit.
+ */
+
+package utils
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/ShogunPanda/impacca/configuration"
+)
+
+func TestLintChangelog(t *testing.T) {
+	defer useTestGitRepository(t, "v1.0.0")()
+
+	runTestGit(t, "commit", "--quiet", "--allow-empty", "--message=feat: Arrays")
+	runTestGit(t, "tag", "v1.1.0")
+	runTestGit(t, "tag", "v1.3.0")
+
+	hash := runTestGit(t, "log", "-n", "1", "--format=%h")
+	date := runTestGit(t, "log", "-n", "1", "--format=%ad", "--date=short")
+
+	changelog := ParseChangelog(fmt.Sprintf(
+		"### %[1]s / 1.1.0\n\n- feat: Arrays ([%[2]s](https://github.com/owner/repo/commit/%[2]s))\n- fix: Crash ([abcdef1](https://github.com/owner/repo/commit/abcdef1))\n\n"+
+			"### 2000-01-01 / 1.0.0\n\n- First release\n\n"+
+			"### %[1]s / 1.0.0\n\n- Duplicate\n\n"+
+			"### %[1]s / 1.2.0\n\n- Wrong order\n\n"+
+			"## 0.9.0\n\n- Wrong heading\n",
+		date, hash,
+	))
+
+	cases := []struct {
+		format   string
+		expected []string
+	}{
+		{
+			"impacca",
+			[]string{
+				"unknown-commit 1.1.0 1", "version-date 1.0.0 6", "duplicate-version 1.0.0 10", "version-order 1.2.0 14",
+				"heading-format 0.9.0 18", "missing-version 1.3.0 0",
+			},
+		},
+		{
+			"keepachangelog",
+			[]string{
+				"heading-format 1.1.0 1", "unknown-commit 1.1.0 1", "heading-format 1.0.0 6", "version-date 1.0.0 6", "heading-format 1.0.0 10",
+				"duplicate-version 1.0.0 10", "heading-format 1.2.0 14", "version-order 1.2.0 14", "heading-format 0.9.0 18",
+				"missing-version 1.3.0 0",
+			},
+		},
+	}
+
+	previous := configuration.Current.Changelog
+	defer func() { configuration.Current.Changelog = previous }()
+
+	for _, tc := range cases {
+		configuration.Current.Changelog.Format = tc.format
+		problems := make([]string, 0)
+
+		for _, problem := range LintChangelog(changelog, GetVersions()) {
+			problems = append(problems, fmt.Sprintf("%s %s %d", problem.Rule, problem.Version, problem.Line))
+		}
+
+		if !reflect.DeepEqual(problems, tc.expected) {
+			t.Errorf("%s: expected problems %v, got %v", tc.format, tc.expected, problems)
+		}
+	}
+}
+
+func TestLintValidChangelog(t *testing.T) {
+	defer useTestGitRepository(t, "v1.0.0")()
+
+	date := runTestGit(t, "log", "-n", "1", "--format=%ad", "--date=short")
+	changelogs := map[string]string{
+		"impacca":        fmt.Sprintf("### %s / 1.0.0\n\n- First release\n", date),
+		"keepachangelog": fmt.Sprintf("# Changelog\n\n## [Unreleased]\n\n## [1.0.0] - %s\n\n### Added\n\n- First release\n", date),
+	}
+
+	previous := configuration.Current.Changelog
+	defer func() { configuration.Current.Changelog = previous }()
+
+	for format, contents := range changelogs {
+		configuration.Current.Changelog.Format = format
+
+		if problems := LintChangelog(ParseChangelog(contents), GetVersions()); len(problems) != 0 {
+			t.Errorf("%s: unexpected problems %+v", format, problems)
+		}
+	}
+}
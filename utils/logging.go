@@ -6,6 +6,7 @@
 package utils
 
 import (
+	"bufio"
 	"fmt"
 	"os"
 	"regexp"
@@ -16,6 +17,7 @@ import (
 )
 
 var outputMutex = sync.Mutex{}
+var confirmMatcher = regexp.MustCompile("(?i)^(y|yes)$")
 
 // ShowDebug is true when DEBUG environment is truthy
 var ShowDebug = regexp.MustCompile("(?i)^(true|yes|y|t|1)$").MatchString(os.Getenv("DEBUG"))
@@ -72,6 +74,18 @@ func Complete() {
 	Success("All operations completed successfully!")
 }
 
+// Confirm asks the user a yes/no question. When not running in a terminal, the answer is always no.
+func Confirm(message string, args ...interface{}) bool {
+	if stat, err := os.Stdin.Stat(); err != nil || stat.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+
+	Log(os.Stdout, tempera.ColorizeTemplate(fmt.Sprintf("%s{bold white}%s{-} [y/N] ", SpacedEmoji("❓"), message)), args...) // Emoji code: 2753
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+
+	return confirmMatcher.MatchString(strings.TrimSpace(answer))
+}
+
 // NotifyStep notifies about a execution of a step
 func NotifyStep(showOnly bool, color, showOnlyVerb, realVerb, message string, args ...interface{}) bool {
 	verb := realVerb
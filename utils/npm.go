@@ -0,0 +1,103 @@
+/*
+ * This file is part of impacca. Copyright (C) 2013 and above Shogun <shogun@cowtech.it>.
+ * Licensed under the MIT license, which can be found at https://choosealicense.com/licenses/mit.
+ */
+
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Masterminds/semver"
+)
+
+type npmPackageJSON struct {
+	Version string `json:"version"`
+	Private bool   `json:"private"`
+}
+
+// npmPackageManager releases using npm
+type npmPackageManager struct {
+	changedFiles
+}
+
+func readNpmPackageJSON(folder string) (*npmPackageJSON, error) {
+	var parsed npmPackageJSON
+	rawConfiguration, err := ioutil.ReadFile(filepath.Join(folder, "package.json"))
+
+	if err == nil {
+		err = json.Unmarshal(rawConfiguration, &parsed)
+	}
+
+	return &parsed, err
+}
+
+func (m *npmPackageManager) Name() string {
+	return "npm"
+}
+
+func (m *npmPackageManager) Detect(folder string) bool {
+	if _, err := os.Stat(filepath.Join(folder, "package.json")); os.IsNotExist(err) {
+		return false
+	}
+
+	// If the package.json file is marked as private, treat as PlainRelease
+	parsed, err := readNpmPackageJSON(folder)
+	return err != nil || !parsed.Private
+}
+
+func (m *npmPackageManager) CurrentVersion() string {
+	cwd, _ := os.Getwd()
+	parsed, _ := readNpmPackageJSON(cwd)
+
+	return parsed.Version
+}
+
+// SetVersion updates the version using npm, which also updates the lock files.
+func (m *npmPackageManager) SetVersion(newVersion, currentVersion *semver.Version, dryRun bool) {
+	cwd, _ := os.Getwd()
+	versionString := FormatVersion(newVersion)
+	m.resetFiles()
+
+	if NotifyExecution(dryRun, "Will execute", "Executing", ": {primary}npm version %s --no-git-tag-version{-} ...", versionString) {
+		result := Execute(true, "npm", "version", versionString, "--no-git-tag-version")
+		result.Verify("npm", "Cannot update NPM version")
+	}
+
+	for _, file := range []string{"package.json", "package-lock.json", "npm-shrinkwrap.json"} {
+		if _, err := os.Stat(filepath.Join(cwd, file)); err == nil {
+			m.trackFile(file)
+		}
+	}
+}
+
+func (m *npmPackageManager) Publish(version *semver.Version, options PublishOptions) {
+	access := "public"
+
+	if options.Private {
+		access = "restricted"
+	}
+
+	// Prereleases must not become the latest version
+	publishArgs := []string{"publish", fmt.Sprintf("--access %s", access)}
+
+	if version.Prerelease() != "" {
+		distTag := PrereleaseIdentifier(version)
+
+		if distTag == "" {
+			distTag = "next"
+		}
+
+		publishArgs = append(publishArgs, fmt.Sprintf("--tag=%s", distTag))
+	}
+
+	if NotifyExecution(options.DryRun, "Will execute", "Executing", ": {primary}npm %s{-} ...", strings.Join(publishArgs, " ")) {
+		result := Execute(true, "npm", publishArgs...)
+		result.Verify("npm", "Cannot publish the package")
+	}
+}
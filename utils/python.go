@@ -0,0 +1,204 @@
+/*
+ * This file is part of impacca. Copyright (C) 2013 and above Shogun <shogun@cowtech.it>.
+ * Licensed under the MIT license, which can be found at https://choosealicense.com/licenses/mit.
+ */
+
+package utils
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/ShogunPanda/impacca/configuration"
+)
+
+var pythonVersionMatcher = regexp.MustCompile("(?m)^(__version__\\s*(?::\\s*str\\s*)?=\\s*[\"'])([^\"']*)([\"'])")
+var pythonNameNormalizer = regexp.MustCompile("[-_.]+")
+var pythonPrereleaseMatcher = regexp.MustCompile("^([A-Za-z]*)[.\\-_]?(\\d*)$")
+
+// See https://peps.python.org/pep-0440/#pre-releases
+var pep440Segments = map[string]string{
+	"a": "a", "alpha": "a", "b": "b", "beta": "b", "c": "rc", "rc": "rc", "pre": "rc", "preview": "rc",
+	"": ".dev", "dev": ".dev", "post": ".post",
+}
+
+// pythonVersionKeys are the keys of pyproject.toml which can contain the version: PEP 621 and Poetry ones.
+var pythonVersionKeys = []string{"project.version", "tool.poetry.version"}
+
+// pythonPackageManager releases Python packages using twine
+type pythonPackageManager struct {
+	changedFiles
+}
+
+// pep440Version formats a version according to PEP 440, like 1.2.3b1 for 1.2.3-beta.1.
+func pep440Version(version *semver.Version) string {
+	stable, _ := version.SetPrerelease("")
+	stable, _ = stable.SetMetadata("")
+	formatted := FormatVersion(&stable)
+
+	if prerelease := version.Prerelease(); prerelease != "" {
+		matches := pythonPrereleaseMatcher.FindStringSubmatch(prerelease)
+		segment, supported := "", false
+
+		if matches != nil {
+			segment, supported = pep440Segments[strings.ToLower(matches[1])]
+		}
+
+		if !supported {
+			Fatal(
+				"Cannot convert the prerelease {errorPrimary}%s{-} to a Python version. Use alpha, beta, rc, dev or post as prerelease identifier.",
+				prerelease,
+			)
+		}
+
+		counter := matches[2]
+		if counter == "" {
+			counter = "0"
+		}
+
+		formatted += segment + counter
+	}
+
+	if metadata := version.Metadata(); metadata != "" {
+		formatted += "+" + metadata
+	}
+
+	return formatted
+}
+
+// pythonModuleFiles returns the modules of a Python package which declare a __version__.
+func pythonModuleFiles(cwd, pyproject string) []string {
+	name := tomlValue(pyproject, "project.name")
+
+	if name == "" {
+		name = tomlValue(pyproject, "tool.poetry.name")
+	}
+
+	// Look for the package folder, either in the flat or in the src layout
+	folders := make([]string, 0)
+	if name != "" {
+		module := strings.ToLower(pythonNameNormalizer.ReplaceAllString(name, "_"))
+		folders = append(folders, filepath.Join(cwd, module), filepath.Join(cwd, "src", module))
+	} else {
+		folders, _ = filepath.Glob(filepath.Join(cwd, "*"))
+		srcFolders, _ := filepath.Glob(filepath.Join(cwd, "src", "*"))
+		folders = append(folders, srcFolders...)
+	}
+
+	files := make([]string, 0)
+
+	for _, folder := range folders {
+		for _, module := range []string{"__init__.py", "__version__.py", "_version.py", "version.py"} {
+			rawContents, err := ioutil.ReadFile(filepath.Join(folder, module))
+
+			if err == nil && pythonVersionMatcher.Match(rawContents) {
+				files = append(files, filepath.Join(folder, module))
+			}
+		}
+	}
+
+	return files
+}
+
+func (m *pythonPackageManager) Name() string {
+	return "python"
+}
+
+func (m *pythonPackageManager) Detect(folder string) bool {
+	_, err := os.Stat(filepath.Join(folder, "pyproject.toml"))
+	return err == nil
+}
+
+func (m *pythonPackageManager) CurrentVersion() string {
+	cwd, _ := os.Getwd()
+	pyproject := readTextFile(filepath.Join(cwd, "pyproject.toml"))
+
+	for _, key := range pythonVersionKeys {
+		if version := tomlValue(pyproject, key); version != "" {
+			return version
+		}
+	}
+
+	if files := pythonModuleFiles(cwd, pyproject); len(files) > 0 {
+		return pythonVersionMatcher.FindStringSubmatch(readTextFile(files[0]))[2]
+	}
+
+	return ""
+}
+
+// SetVersion updates the version of a Python package, in pyproject.toml and in the __version__ of its modules.
+func (m *pythonPackageManager) SetVersion(newVersion, currentVersion *semver.Version, dryRun bool) {
+	cwd, _ := os.Getwd()
+	m.resetFiles()
+	version := pep440Version(newVersion)
+	pyprojectPath := filepath.Join(cwd, "pyproject.toml")
+	rawPyproject, err := ioutil.ReadFile(pyprojectPath)
+
+	if err != nil {
+		Fatal("Cannot read file {errorPrimary}pyproject.toml{-}: {errorPrimary}%s{-}", err.Error())
+	}
+
+	pyproject := string(rawPyproject)
+	updatedPyproject := pyproject
+	count := 0
+
+	// The version is not in pyproject.toml when it is dynamic
+	for _, key := range pythonVersionKeys {
+		var replaced int
+		updatedPyproject, replaced, _ = replaceTOML(updatedPyproject, key, version)
+		count += replaced
+	}
+
+	if updatedPyproject != pyproject {
+		writeUpdatedFile(pyprojectPath, "pyproject.toml", pyproject, updatedPyproject, dryRun)
+		m.trackFile(pyprojectPath)
+	}
+
+	for _, file := range pythonModuleFiles(cwd, pyproject) {
+		relative, _ := filepath.Rel(cwd, file)
+		rawContents, err := ioutil.ReadFile(file)
+
+		if err != nil {
+			Fatal("Cannot read file {errorPrimary}%s{-}: {errorPrimary}%s{-}", relative, err.Error())
+		}
+
+		contents := string(rawContents)
+		writeUpdatedFile(file, relative, contents, pythonVersionMatcher.ReplaceAllString(contents, "${1}"+version+"${3}"), dryRun)
+		m.trackFile(file)
+		count++
+	}
+
+	if count == 0 {
+		Fatal("Cannot find the version in {errorPrimary}pyproject.toml{-} or in the {errorPrimary}__version__{-} of the package modules.")
+	}
+}
+
+// Publish builds the sdist and wheel distributions and uploads them using twine.
+func (m *pythonPackageManager) Publish(version *semver.Version, options PublishOptions) {
+	// Build in a temporary folder so that only the new distributions are uploaded
+	distFolder := filepath.Join(os.TempDir(), fmt.Sprintf("impacca-dist-%d", os.Getpid()))
+	defer os.RemoveAll(distFolder)
+
+	if NotifyExecution(options.DryRun, "Will execute", "Executing", ": {primary}python3 -m build --sdist --wheel{-} ...") {
+		result := Execute(true, "python3", "-m", "build", "--sdist", "--wheel", "--outdir", distFolder)
+		result.Verify("python3", "Cannot build the package")
+	}
+
+	uploadArgs := []string{"-m", "twine", "upload", "--non-interactive"}
+
+	if repository := configuration.Current.Python.Repository; repository != "" {
+		uploadArgs = append(uploadArgs, "--repository-url", repository)
+	}
+
+	if NotifyExecution(options.DryRun, "Will execute", "Executing", ": {primary}python3 %s{-} ...", strings.Join(uploadArgs, " ")) {
+		distributions, _ := filepath.Glob(filepath.Join(distFolder, "*"))
+
+		result := Execute(true, "python3", append(uploadArgs, distributions...)...)
+		result.Verify("twine", "Cannot upload the package")
+	}
+}
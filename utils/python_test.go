@@ -0,0 +1,125 @@
+/*
+ * This file is part of impacca. Copyright (C) 2013 and above Shogun <shogun@cowtech.it>.
+ * Licensed under the MIT license, which can be found at https://choosealicense.com/licenses/mit.
+ */
+
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Masterminds/semver"
+	"github.com/ShogunPanda/impacca/configuration"
+)
+
+func TestPEP440Version(t *testing.T) {
+	cases := map[string]string{
+		"1.2.3":            "1.2.3",
+		"1.2.3-alpha.1":    "1.2.3a1",
+		"1.2.3-beta.2":     "1.2.3b2",
+		"1.2.3-b":          "1.2.3b0",
+		"1.2.3-rc.0":       "1.2.3rc0",
+		"1.2.3-preview.4":  "1.2.3rc4",
+		"1.2.3-dev.5":      "1.2.3.dev5",
+		"1.2.3-1":          "1.2.3.dev1",
+		"1.2.3-post.2":     "1.2.3.post2",
+		"1.2.3-beta.1+abc": "1.2.3b1+abc",
+	}
+
+	for version, expected := range cases {
+		if actual := pep440Version(semver.MustParse(version)); actual != expected {
+			t.Errorf("%s: expected %s, got %s", version, expected, actual)
+		}
+	}
+}
+
+func TestPythonSetVersion(t *testing.T) {
+	cases := []struct {
+		name     string
+		files    map[string]string
+		expected map[string]string
+	}{
+		{
+			"PEP 621",
+			map[string]string{"pyproject.toml": "[project]\nname = \"my-app\"\nversion = \"1.2.3\"\n", "my_app/__init__.py": "__version__ = \"1.2.3\"\n"},
+			map[string]string{"pyproject.toml": "[project]\nname = \"my-app\"\nversion = \"1.3.0b1\"\n", "my_app/__init__.py": "__version__ = \"1.3.0b1\"\n"},
+		},
+		{
+			"Poetry",
+			map[string]string{"pyproject.toml": "[tool.poetry]\nname = \"my.app\"\nversion = '1.2.3'\n"},
+			map[string]string{"pyproject.toml": "[tool.poetry]\nname = \"my.app\"\nversion = '1.3.0b1'\n"},
+		},
+		{
+			"dynamic version",
+			map[string]string{
+				"pyproject.toml":      "[project]\nname = \"app\"\ndynamic = [\"version\"]\n",
+				"src/app/_version.py": "__version__: str = '1.2.3'\n",
+				"src/app/__init__.py": "from ._version import __version__\n",
+			},
+			map[string]string{"src/app/_version.py": "__version__: str = '1.3.0b1'\n", "src/app/__init__.py": "from ._version import __version__\n"},
+		},
+	}
+
+	for _, tc := range cases {
+		done := useTestFolder(t, tc.files)
+		manager := &pythonPackageManager{}
+
+		if !manager.Detect(".") || manager.CurrentVersion() != "1.2.3" {
+			t.Errorf("%s: unexpected current version %s", tc.name, manager.CurrentVersion())
+		}
+
+		manager.SetVersion(semver.MustParse("1.3.0-beta.1"), semver.MustParse("1.2.3"), false)
+
+		changed := 0
+
+		for file, expected := range tc.expected {
+			if actual := readTextFile(file); actual != expected {
+				t.Errorf("%s: unexpected contents of %s:\n%s", tc.name, file, actual)
+			} else if expected != tc.files[file] {
+				changed++
+			}
+		}
+
+		if len(manager.Files()) != changed {
+			t.Errorf("%s: unexpected changed files %v", tc.name, manager.Files())
+		}
+
+		done()
+	}
+}
+
+func TestPythonPublishUsesConfiguredRepository(t *testing.T) {
+	done := useTestFolder(t, map[string]string{
+		"bin/python3": "#!/bin/sh\necho \"$@\" >> \"$IMPACCA_TEST_LOG\"\nif [ \"$2\" = build ]; then mkdir -p \"$6\" && touch \"$6/app-1.3.0.tar.gz\"; fi\n",
+	})
+	defer done()
+
+	cwd, _ := os.Getwd()
+	os.Chmod(filepath.Join(cwd, "bin", "python3"), 0755)
+
+	path := os.Getenv("PATH")
+	defer os.Setenv("PATH", path)
+	defer os.Unsetenv("IMPACCA_TEST_LOG")
+
+	os.Setenv("PATH", filepath.Join(cwd, "bin")+string(os.PathListSeparator)+path)
+	os.Setenv("IMPACCA_TEST_LOG", filepath.Join(cwd, "python.log"))
+
+	previous := configuration.Current.Python
+	configuration.Current.Python.Repository = "http://localhost:8080"
+	defer func() { configuration.Current.Python = previous }()
+
+	(&pythonPackageManager{}).Publish(semver.MustParse("1.3.0"), PublishOptions{})
+
+	lines, _ := splitLines(strings.TrimSpace(readTextFile("python.log")))
+
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "-m build --sdist --wheel --outdir ") {
+		t.Fatalf("unexpected commands: %v", lines)
+	}
+
+	if !strings.HasPrefix(lines[1], "-m twine upload --non-interactive --repository-url http://localhost:8080 ") || !strings.HasSuffix(lines[1], "/app-1.3.0.tar.gz") {
+		t.Errorf("unexpected upload command: %s", lines[1])
+	}
+}
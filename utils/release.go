@@ -6,56 +6,112 @@
 package utils
 
 import (
-	"fmt"
-	"regexp"
 	"strings"
 	"time"
 
-	"gopkg.in/h2non/gentleman.v2"
-	"gopkg.in/h2non/gentleman.v2/plugins/body"
 	"github.com/Masterminds/semver"
+	"gopkg.in/h2non/gentleman.v2"
 )
 
-// Release represents a GitHub API release
+// Release represents a release hosted on a forge
 type Release struct {
-	ID 			int 						`json:"id"`
-	Version *semver.Version `json:"name"`
-	Date 		*time.Time 			`json:"created_at"`
-	Body 		string 					`json:"body"`
+	// ID is the identifier of the release on the forge, if the forge uses one.
+	ID         int
+	Tag        string
+	Name       string
+	Version    *semver.Version
+	Date       *time.Time
+	Body       string
+	Prerelease bool
+}
+
+// ReleaseProvider manages the releases of the repositories hosted on a forge
+type ReleaseProvider interface {
+	// Name returns the name used to select the provider, like github.
+	Name() string
+	// Title returns the name of the forge shown to the user, like GitHub.
+	Title() string
+	// Detect returns the repository of a GIT remote, parsed by parseRemoteURL, or nil if the remote is not hosted on the forge.
+	Detect(host, baseURL, path string) *Repository
+	// List returns the releases of a repository whose name or tag is a version.
+	List(repository *Repository, token string) []Release
+	// Get returns the release of a tag, or nil if it does not exist.
+	Get(repository *Repository, token, tag string) *Release
+	// Create creates a release and returns it.
+	Create(repository *Repository, token string, release *Release) *Release
+	// Update updates the release with the same ID and tag and returns it.
+	Update(repository *Repository, token string, release *Release) *Release
+	// Delete deletes a release, keeping its GIT tag.
+	Delete(repository *Repository, token string, release *Release)
+	// ListAssets returns the files attached to a release.
+	ListAssets(repository *Repository, token string, release *Release) []ReleaseAsset
+	// UploadAsset uploads a file to a release.
+	UploadAsset(repository *Repository, token string, release *Release, file string)
+	// ReplaceAsset uploads a file to a release replacing an asset with the same name, which is only deleted after the upload.
+	ReplaceAsset(repository *Repository, token string, release *Release, asset ReleaseAsset, file string)
+	// DeleteAsset deletes a file attached to a release.
+	DeleteAsset(repository *Repository, token string, release *Release, asset ReleaseAsset)
+}
+
+// ReleaseProviders contains the available release providers, in detection order.
+var ReleaseProviders = []ReleaseProvider{
+	&githubReleaseProvider{},
+	&giteaReleaseProvider{},
+	&gitlabReleaseProvider{},
+}
+
+// ReleaseProviderNames returns the names of all release providers.
+func ReleaseProviderNames() []string {
+	names := make([]string, len(ReleaseProviders))
+
+	for i, provider := range ReleaseProviders {
+		names[i] = provider.Name()
+	}
+
+	return names
 }
 
-var remoteMatcher, _ = regexp.Compile("(?i)^.+github\\.com[:/](.+)\\.git$")
+// FindReleaseProvider finds a release provider by name.
+func FindReleaseProvider(name string) ReleaseProvider {
+	for _, provider := range ReleaseProviders {
+		if strings.EqualFold(provider.Name(), name) {
+			return provider
+		}
+	}
 
-// GitHubReleaseAPICall performs a GitHub release API call.
-func GitHubReleaseAPICall(message, method, path, token string, data map[string]string, allowErrors bool) *gentleman.Response {
-	cli := gentleman.New()
-  cli.URL("https://api.github.com")
+	return nil
+}
 
-	req := cli.Request()
-	req.Method(method)
-	req.Path(path)
-	
-	if token != "" {
-		req.SetHeader("Authorization", fmt.Sprintf("Bearer %s", token))
+// releaseVersion returns the version of a release from its name or, when the name is not a version, from its tag.
+func releaseVersion(name, tag string) *semver.Version {
+	if version, err := semver.NewVersion(name); err == nil {
+		return version
 	}
 
-	if method != "GET" {
-		req.Use(body.JSON(data))
+	if tagVersion, isVersion := parseVersionTag(tag); isVersion {
+		if version, err := semver.NewVersion(tagVersion); err == nil {
+			return version
+		}
 	}
-	
-  // Perform the request
-  res, err := req.Send()
+
+	return nil
+}
+
+// sendReleaseRequest performs a forge API request, failing on network and HTTP errors.
+// When allowNotFound is true, a 404 response is returned to the caller instead.
+func sendReleaseRequest(message string, req *gentleman.Request, allowNotFound bool) *gentleman.Response {
+	res, err := req.Send()
 
 	if err != nil {
 		Fatal("Cannot %s due to a network error: {errorPrimary}%s{-}", message, err.Error())
-  }
+	}
 
 	if !res.Ok {
-		if res.StatusCode == 401 { 
+		if res.StatusCode == 401 {
 			Fatal("Cannot %s due to an authentication error.", message)
-		} else if !allowErrors {
+		} else if !allowNotFound || res.StatusCode != 404 {
 			Fatal(
-				"Cannot %s due to an HTTP error: {secondary}[HTTP %d]{-} {errorPrimary}%s{-}", 
+				"Cannot %s due to an HTTP error: {secondary}[HTTP %d]{-} {errorPrimary}%s{-}",
 				message, res.StatusCode, res.String(),
 			)
 		}
@@ -64,46 +120,27 @@ func GitHubReleaseAPICall(message, method, path, token string, data map[string]s
 	return res
 }
 
-// DetectGithubRepository detects the GitHub repository.
-func DetectGithubRepository(remote string, allowFailure bool) string {
-	result := Execute(false, "git", "remote", "get-url", remote)
-	result.Verify("git", "Cannot get GIT remote url")
-
-	remoteURL := strings.TrimSpace(result.Stdout)
-	if !strings.HasPrefix(remoteURL, "https://github.com") && !strings.HasPrefix(remoteURL, "git@github.com") {
-		if allowFailure {
-			return ""
+// hasNextPage checks if a paginated API response is followed by another page, using the Link header.
+func hasNextPage(res *gentleman.Response) bool {
+	for _, link := range strings.Split(res.Header.Get("Link"), ",") {
+		if strings.Contains(link, `rel="next"`) {
+			return true
 		}
-
-		Fatal("The GIT remote {errorPrimary}%s{-} is not a GitHub repository.", remote)
 	}
 
-	return remoteMatcher.FindStringSubmatch(remoteURL)[1]
+	return false
 }
 
-// FindRelease finds a release id on GitHub API.
-func FindRelease(repository, token, version string) int {
-	res := GitHubReleaseAPICall(
-		"find a GitHub release", "GET", fmt.Sprintf("/repos/%s/releases/tags/v%s", repository, version), 
-		token, map[string]string{}, true,
-	)
-
-	if res.StatusCode == 404 {
-		return 0
-	} 
-
-	var release Release
-	err := res.JSON(&release)
-	
-	if err != nil {
-		Fatal("Cannot decode JSON response to %s: {errorPrimary}%s{-}", err.Error())
-	}	
+// GetReleaseBody returns the body of a release, using the CHANGELOG.md version section if requested and available.
+func GetReleaseBody(version *semver.Version, repository *Repository, fromChangelog bool) string {
+	if fromChangelog {
+		if section := ReadParsedChangelog().Find(FormatVersion(version)); section != nil && section.Content() != "" {
+			return section.Content()
+		}
 
-	return release.ID
-}
+		Warn("The version {secondary}%s{-} is not in the CHANGELOG.md file, using the list of changes.", FormatVersion(version))
+	}
 
-// SaveRelease creates or updates a release on GitHub 
-func SaveRelease(version *semver.Version, repository, remote, token string, dryRun bool) {
 	// Get and format changes
 	versions := GetVersions()
 
@@ -116,38 +153,40 @@ func SaveRelease(version *semver.Version, repository, remote, token string, dryR
 	}
 
 	var changes []Change
+	var previousVersion *semver.Version
 
 	if currentIndex > 0 {
-		previousVersion := versions[currentIndex-1]
-		changes = ListChanges(version.String(), previousVersion.String())
+		previousVersion = versions[currentIndex-1]
+		changes = ListChanges(FormatVersion(version), FormatVersion(previousVersion))
 	} else {
-		changes = ListChanges(version.String(), GetFirstCommitHash())
+		changes = ListChanges(FormatVersion(version), GetFirstCommitHash())
 	}
 
-	changelog := strings.TrimSpace(FormatReleaseChanges(repository, changes))
-	data := map[string]string{
-		"tag_name": fmt.Sprintf("v%s", version.String()),
-		"name": version.String(),
-		"body": changelog,
+	return strings.TrimSpace(FormatReleaseChanges(repository, version, previousVersion, changes))
+}
+
+// SaveRelease creates or updates the release of a version. It returns nil in dry-run mode.
+func SaveRelease(version *semver.Version, repository *Repository, token string, fromChangelog, dryRun bool) *Release {
+	provider := repository.Provider
+	release := &Release{
+		Tag:        VersionTag(FormatVersion(version)),
+		Name:       FormatVersion(version),
+		Version:    version,
+		Body:       GetReleaseBody(version, repository, fromChangelog),
+		Prerelease: version.Prerelease() != "",
 	}
 
-	// Check if a release exists
-	existing := FindRelease(repository, "", version.String())
+	// Check if a release exists and perform the right operation
+	var saved *Release
 
-	// Perform the right operation on GitHub
-	if existing != 0 {
-		if NotifyStep(dryRun, "", "Will update", "Updating", " GitHub release {primary}%s{-}...", version.String()) {
-			GitHubReleaseAPICall(
-				"update a GitHub release", "PATCH", fmt.Sprintf("/repos/%s/releases/%d", repository, existing), 
-				token, data, false,
-			)
-		}
-	} else {
-		if NotifyStep(dryRun, "", "Will create", "Creating", " GitHub release {primary}%s{-}...", version.String()) {
-			GitHubReleaseAPICall(
-				"create a GitHub release", "POST", fmt.Sprintf("/repos/%s/releases", repository), 
-				token, data, false,
-			)
+	if existing := provider.Get(repository, token, release.Tag); existing != nil {
+		if NotifyStep(dryRun, "", "Will update", "Updating", " %s release {primary}%s{-}...", provider.Title(), release.Name) {
+			release.ID = existing.ID
+			saved = provider.Update(repository, token, release)
 		}
+	} else if NotifyStep(dryRun, "", "Will create", "Creating", " %s release {primary}%s{-}...", provider.Title(), release.Name) {
+		saved = provider.Create(repository, token, release)
 	}
-}
\ No newline at end of file
+
+	return saved
+}
@@ -0,0 +1,93 @@
+/*
+ * This file is part of impacca. Copyright (C) 2013 and above Shogun <shogun@cowtech.it>.
+ * Licensed under the MIT license, which can be found at https://choosealicense.com/licenses/mit.
+ */
+
+package utils
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Masterminds/semver"
+	"github.com/ShogunPanda/impacca/configuration"
+)
+
+func TestGetReleaseBody(t *testing.T) {
+	defer useTestGitRepository(t, "v1.0.0")()
+
+	runTestGit(t, "commit", "--quiet", "--allow-empty", "--message=feat: Arrays")
+	runTestGit(t, "tag", "v1.1.0")
+	hash := runTestGit(t, "log", "-n", "1", "--format=%h")
+	WriteChangelog("### 2020-02-01 / 1.1.0\n\nHand written notes.\n\n### 2020-01-01 / 1.0.0\n\n")
+
+	generated := "- feat: Arrays ([" + hash + "](https://github.com/owner/repo/commit/" + hash + "))"
+
+	cases := []struct {
+		version       string
+		fromChangelog bool
+		expected      string
+	}{
+		{"1.1.0", true, "Hand written notes."},
+		{"1.1.0", false, generated},
+		{"1.0.0", true, ""},
+	}
+
+	for _, tc := range cases {
+		if actual := GetReleaseBody(semver.MustParse(tc.version), testGitHubRepository, tc.fromChangelog); actual != tc.expected {
+			t.Errorf("%s (%v): expected %q, got %q", tc.version, tc.fromChangelog, tc.expected, actual)
+		}
+	}
+
+	WriteChangelog("### 2020-01-01 / 1.0.0\n\n- First release\n")
+
+	if actual := GetReleaseBody(semver.MustParse("1.1.0"), testGitHubRepository, true); actual != generated {
+		t.Errorf("expected the list of changes, got %q", actual)
+	}
+}
+
+func TestReleaseProvidersGet(t *testing.T) {
+	cases := []struct {
+		status  int
+		missing bool
+	}{
+		{http.StatusOK, false},
+		{http.StatusNotFound, true},
+	}
+
+	for _, provider := range ReleaseProviders {
+		for _, tc := range cases {
+			baseURL, done := useTestAPIServer(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+				w.Write([]byte(`{"id": 1, "tag_name": "v1.2.3", "name": "1.2.3"}`))
+			})
+
+			configuration.Current.GitHub.APIURL = baseURL
+			repository := &Repository{Provider: provider, BaseURL: baseURL, Path: "owner/repo"}
+
+			if release := provider.Get(repository, "token", "v1.2.3"); (release == nil) != tc.missing {
+				t.Errorf("%s with HTTP %d: unexpected release %+v", provider.Name(), tc.status, release)
+			}
+
+			done()
+		}
+	}
+}
+
+func TestReleaseProvidersGetFailsOnServerErrors(t *testing.T) {
+	for _, provider := range ReleaseProviders {
+		t.Run(provider.Name(), func(t *testing.T) {
+			expectFatal(t, "Internal server failure", func() {
+				baseURL, done := useTestAPIServer(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusInternalServerError)
+					w.Write([]byte("Internal server failure"))
+				})
+
+				defer done()
+
+				configuration.Current.GitHub.APIURL = baseURL
+				provider.Get(&Repository{Provider: provider, BaseURL: baseURL, Path: "owner/repo"}, "token", "v1.2.3")
+			})
+		})
+	}
+}
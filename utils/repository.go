@@ -0,0 +1,186 @@
+/*
+ * This file is part of impacca. Copyright (C) 2013 and above Shogun <shogun@cowtech.it>.
+ * Licensed under the MIT license, which can be found at https://choosealicense.com/licenses/mit.
+ */
+
+package utils
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/ShogunPanda/impacca/configuration"
+)
+
+const (
+	githubForge = "github"
+	gitlabForge = "gitlab"
+	giteaForge  = "gitea"
+)
+
+// Repository identifies a project hosted on a forge, like GitHub, GitLab or Gitea
+type Repository struct {
+	Provider ReleaseProvider
+	BaseURL  string
+	Path     string
+}
+
+// Matches https://host/path.git, ssh://user@host:port/path.git and user@host:path.git, with or without .git.
+// Ports are only allowed in URLs, as in user@host:1234/path.git the number is part of the path.
+var remoteURLMatcher = regexp.MustCompile(
+	"^(?:([a-zA-Z][a-zA-Z0-9+.-]*)://(?:[^@/]+@)?([^:/]+)(?::(\\d+))?/|(?:[^@/]+@)?([^:/]+):)(.+?)(?:\\.git)?/*$",
+)
+
+var sshHostnameMatcher = regexp.MustCompile("(?m)^hostname\\s+(\\S+)$")
+
+// resolveSSHHost resolves an alias defined in the SSH configuration, like github-work, to its real host.
+// If SSH is not available, the host is returned unchanged.
+func resolveSSHHost(host string) string {
+	result := Execute(false, "ssh", "-G", host)
+
+	if result.Error == nil && result.ExitCode == 0 {
+		if matches := sshHostnameMatcher.FindStringSubmatch(result.Stdout); matches != nil {
+			return strings.ToLower(matches[1])
+		}
+	}
+
+	return host
+}
+
+// matchHost checks if a remote host is in a list of configured hosts.
+// Hosts configured as URLs, like http://localhost:3000, also override the base URL detected from the remote.
+func matchHost(hosts []string, host, baseURL string) (string, bool) {
+	hostname := strings.Split(host, ":")[0]
+
+	for _, configured := range hosts {
+		if parsed, err := url.Parse(configured); err == nil && parsed.Scheme != "" && parsed.Host != "" {
+			if strings.EqualFold(parsed.Host, host) || strings.EqualFold(parsed.Hostname(), hostname) {
+				return strings.TrimSuffix(configured, "/"), true
+			}
+		} else if strings.EqualFold(configured, host) || strings.EqualFold(configured, hostname) {
+			return baseURL, true
+		}
+	}
+
+	return "", false
+}
+
+// parseRemoteURL returns the host, the web base URL and the project path of a GIT remote URL.
+// Remotes not using HTTP are assumed to be served over HTTPS on the default port and their host can be an SSH alias.
+func parseRemoteURL(remoteURL string) (host, baseURL, path string, ok bool) {
+	matches := remoteURLMatcher.FindStringSubmatch(strings.TrimSpace(remoteURL))
+
+	if matches == nil {
+		return "", "", "", false
+	}
+
+	scheme, host, port, path := strings.ToLower(matches[1]), strings.ToLower(matches[2]+matches[4]), matches[3], matches[5]
+
+	if scheme == "http" || scheme == "https" {
+		if port != "" {
+			host += ":" + port
+		}
+
+		return host, scheme + "://" + host, path, true
+	}
+
+	host = resolveSSHHost(host)
+	return host, "https://" + host, path, true
+}
+
+// DetectRepository detects the forge repository of a GIT remote.
+// The release provider is detected from the remote URL, unless it is set in the configuration.
+func DetectRepository(remote string, allowFailure bool) *Repository {
+	result := Execute(false, "git", "remote", "get-url", remote)
+
+	if allowFailure && (result.Error != nil || result.ExitCode != 0) {
+		return nil
+	}
+
+	result.Verify("git", "Cannot get GIT remote url")
+
+	var provider ReleaseProvider
+
+	if name := configuration.Current.Release.Provider; name != "" {
+		provider = FindReleaseProvider(name)
+
+		if provider == nil {
+			Fatal(
+				"Unsupported release provider {errorPrimary}%s{-}. Supported ones are: {errorPrimary}%s{-}.",
+				name, strings.Join(ReleaseProviderNames(), ", "),
+			)
+		}
+	}
+
+	// The remote is parsed once, as resolving SSH aliases executes ssh
+	if host, baseURL, path, ok := parseRemoteURL(result.Stdout); ok {
+		if provider != nil {
+			// Hosts not recognized by the provider are used as they are
+			if repository := provider.Detect(host, baseURL, path); repository != nil {
+				return repository
+			}
+
+			return &Repository{Provider: provider, BaseURL: baseURL, Path: path}
+		}
+
+		for _, provider := range ReleaseProviders {
+			if repository := provider.Detect(host, baseURL, path); repository != nil {
+				return repository
+			}
+		}
+	}
+
+	if !allowFailure {
+		Fatal(
+			"The GIT remote {errorPrimary}%s{-} is not hosted on a supported forge. Supported ones are: {errorPrimary}%s{-}.",
+			remote, strings.Join(ReleaseProviderNames(), ", "),
+		)
+	}
+
+	return nil
+}
+
+// Token returns the API token to use, falling back to the provider environment variable, like IMPACCA_GITHUB_TOKEN.
+func (r *Repository) Token(token string) string {
+	if token == "" {
+		token = os.Getenv(fmt.Sprintf("IMPACCA_%s_TOKEN", strings.ToUpper(r.Provider.Name())))
+	}
+
+	return token
+}
+
+// URL returns the web URL of the repository.
+func (r *Repository) URL() string {
+	return r.BaseURL + "/" + r.Path
+}
+
+// pageURL returns the URL of a page of the repository. GitLab nests pages in the "-" scope.
+func (r *Repository) pageURL(page string) string {
+	if r.Provider.Name() == gitlabForge {
+		return r.URL() + "/-/" + page
+	}
+
+	return r.URL() + "/" + page
+}
+
+// CommitURL returns the web URL of a commit.
+func (r *Repository) CommitURL(hash string) string {
+	return r.pageURL("commit/" + hash)
+}
+
+// CompareURL returns the web URL of the comparison of two GIT references.
+func (r *Repository) CompareURL(from, to string) string {
+	return r.pageURL(fmt.Sprintf("compare/%s...%s", from, to))
+}
+
+// ReleaseURL returns the web URL of the release of a tag.
+func (r *Repository) ReleaseURL(tag string) string {
+	if r.Provider.Name() == gitlabForge {
+		return r.pageURL("releases/" + tag)
+	}
+
+	return r.pageURL("releases/tag/" + tag)
+}
@@ -0,0 +1,244 @@
+/*
+ * This file is part of impacca. Copyright (C) 2013 and above Shogun <shogun@cowtech.it>.
+ * Licensed under the MIT license, which can be found at https://choosealicense.com/licenses/mit.
+ */
+
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver"
+	"github.com/ShogunPanda/impacca/configuration"
+)
+
+// VersioningScheme parses, formats and increments versions.
+// Versions are always carried as semantic versions: each scheme maps its own format to the major, minor and patch numbers.
+type VersioningScheme interface {
+	Parse(version string) (*semver.Version, error)
+	Format(version *semver.Version) string
+	// Increment applies a patch, minor or major change to a version.
+	Increment(version *semver.Version, change string) *semver.Version
+}
+
+// VersioningSchemes contains the available versioning schemes, by name.
+var VersioningSchemes = map[string]func(settings configuration.VersioningSettings) VersioningScheme{
+	"semver": newSemVerScheme,
+	"calver": newCalVerScheme,
+}
+
+var currentVersioningScheme VersioningScheme
+
+// CurrentVersioningScheme returns the configured versioning scheme.
+func CurrentVersioningScheme() VersioningScheme {
+	if currentVersioningScheme == nil {
+		settings := configuration.Current.Versioning
+		factory, found := VersioningSchemes[strings.ToLower(settings.Scheme)]
+
+		if !found {
+			Fatal("Unsupported versioning scheme {errorPrimary}%s{-}.", settings.Scheme)
+		}
+
+		currentVersioningScheme = factory(settings)
+	}
+
+	return currentVersioningScheme
+}
+
+// ParseVersion parses a version using the configured versioning scheme.
+func ParseVersion(version string) (*semver.Version, error) {
+	return CurrentVersioningScheme().Parse(version)
+}
+
+// FormatVersion formats a version using the configured versioning scheme.
+func FormatVersion(version *semver.Version) string {
+	return CurrentVersioningScheme().Format(version)
+}
+
+type semVerScheme struct{}
+
+func newSemVerScheme(settings configuration.VersioningSettings) VersioningScheme {
+	return semVerScheme{}
+}
+
+func (s semVerScheme) Parse(version string) (*semver.Version, error) {
+	return semver.NewVersion(version)
+}
+
+func (s semVerScheme) Format(version *semver.Version) string {
+	return version.String()
+}
+
+func (s semVerScheme) Increment(version *semver.Version, change string) *semver.Version {
+	stable, _ := version.SetPrerelease("")
+	var newVersion semver.Version
+
+	switch change {
+	case "patch":
+		newVersion = version.IncPatch()
+	case "minor":
+		// Releasing a minor prerelease only removes the prerelease
+		if version.Prerelease() != "" && version.Patch() == 0 {
+			newVersion = stable
+		} else {
+			newVersion = version.IncMinor()
+		}
+	case "major":
+		// Releasing a major prerelease only removes the prerelease
+		if version.Prerelease() != "" && version.Minor() == 0 && version.Patch() == 0 {
+			newVersion = stable
+		} else {
+			newVersion = version.IncMajor()
+		}
+	}
+
+	return &newVersion
+}
+
+// calVerScheme implements the https://calver.org scheme, using up to three parts
+type calVerScheme struct {
+	pattern string
+	tokens  []string
+}
+
+var calVerPaddedTokens = map[string]bool{"0Y": true, "0M": true, "0W": true, "0D": true}
+var calVerCounters = map[string]string{"major": "MAJOR", "minor": "MINOR", "patch": "MICRO"}
+var calVerPriorities = map[string]int{"MICRO": 1, "MINOR": 2, "MAJOR": 3}
+
+func newCalVerScheme(settings configuration.VersioningSettings) VersioningScheme {
+	tokens := strings.Split(settings.Pattern, ".")
+
+	if len(tokens) > 3 {
+		Fatal("The CalVer pattern {errorPrimary}%s{-} cannot have more than three parts.", settings.Pattern)
+	}
+
+	for _, token := range tokens {
+		if _, isDate := calVerDateValue(token, time.Now()); !isDate && calVerPriorities[token] == 0 {
+			Fatal("The CalVer pattern {errorPrimary}%s{-} contains the unsupported part {errorPrimary}%s{-}.", settings.Pattern, token)
+		}
+	}
+
+	return &calVerScheme{pattern: settings.Pattern, tokens: tokens}
+}
+
+// calVerDateValue returns the value of a date part, if the token is a date part.
+func calVerDateValue(token string, date time.Time) (int64, bool) {
+	switch token {
+	case "YYYY":
+		return int64(date.Year()), true
+	case "YY", "0Y":
+		return int64(date.Year() - 2000), true
+	case "MM", "0M":
+		return int64(date.Month()), true
+	case "WW", "0W":
+		_, week := date.ISOWeek()
+		return int64(week), true
+	case "DD", "0D":
+		return int64(date.Day()), true
+	}
+
+	return 0, false
+}
+
+func (s *calVerScheme) components(version *semver.Version) []int64 {
+	return []int64{version.Major(), version.Minor(), version.Patch()}
+}
+
+func (s *calVerScheme) Parse(version string) (*semver.Version, error) {
+	parsed, err := semver.NewVersion(version)
+
+	if err != nil {
+		return nil, err
+	}
+
+	core := strings.SplitN(strings.SplitN(strings.TrimPrefix(version, "v"), "-", 2)[0], "+", 2)[0]
+
+	if len(strings.Split(core, ".")) > len(s.tokens) {
+		return nil, fmt.Errorf("version %s does not match the pattern %s", version, s.pattern)
+	}
+
+	return parsed, nil
+}
+
+func (s *calVerScheme) Format(version *semver.Version) string {
+	components := s.components(version)
+	parts := make([]string, len(s.tokens))
+
+	for i, token := range s.tokens {
+		if calVerPaddedTokens[token] {
+			parts[i] = fmt.Sprintf("%02d", components[i])
+		} else {
+			parts[i] = fmt.Sprintf("%d", components[i])
+		}
+	}
+
+	formatted := strings.Join(parts, ".")
+
+	if version.Prerelease() != "" {
+		formatted += "-" + version.Prerelease()
+	}
+
+	if version.Metadata() != "" {
+		formatted += "+" + version.Metadata()
+	}
+
+	return formatted
+}
+
+func (s *calVerScheme) Increment(version *semver.Version, change string) *semver.Version {
+	current := s.components(version)
+	next := make([]int64, 3)
+	copy(next, current)
+	dateChanged := false
+
+	// Update the date parts
+	for i, token := range s.tokens {
+		if value, isDate := calVerDateValue(token, time.Now()); isDate {
+			next[i] = value
+			dateChanged = dateChanged || value != current[i]
+		}
+	}
+
+	// Releasing a prerelease on the same date only removes the prerelease
+	if !dateChanged && version.Prerelease() != "" {
+		stable, _ := version.SetPrerelease("")
+		return &stable
+	}
+
+	// Find the counter to increment: the one matching the change or the least significant one
+	target := -1
+	for i, token := range s.tokens {
+		if token == calVerCounters[change] {
+			target = i
+			break
+		} else if calVerPriorities[token] > 0 && (target == -1 || calVerPriorities[token] < calVerPriorities[s.tokens[target]]) {
+			target = i
+		}
+	}
+
+	if !dateChanged && target == -1 {
+		Fatal(
+			"Cannot change the version {errorPrimary}%s{-} as the pattern {errorPrimary}%s{-} has no counters and the date did not change.",
+			s.Format(version), s.pattern,
+		)
+	}
+
+	// When the date changes all counters but MAJOR restart, otherwise less significant counters restart
+	for i, token := range s.tokens {
+		priority := calVerPriorities[token]
+
+		switch {
+		case priority == 0:
+			continue
+		case i == target && (!dateChanged || (token == "MAJOR" && change == "major")):
+			next[i]++
+		case token != "MAJOR" && (dateChanged || priority < calVerPriorities[s.tokens[target]]):
+			next[i] = 0
+		}
+	}
+
+	newVersion, _ := semver.NewVersion(fmt.Sprintf("%d.%d.%d", next[0], next[1], next[2]))
+	return newVersion
+}
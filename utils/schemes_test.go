@@ -0,0 +1,70 @@
+/*
+ * This file is part of impacca. Copyright (C) 2013 and above Shogun <shogun@cowtech.it>.
+ * Licensed under the MIT license, which can be found at https://choosealicense.com/licenses/mit.
+ */
+
+package utils
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/semver"
+	"github.com/ShogunPanda/impacca/configuration"
+)
+
+func TestCalVerIncrement(t *testing.T) {
+	now := time.Now()
+	year, month, shortYear := now.Year(), int(now.Month()), now.Year()-2000
+
+	cases := []struct {
+		pattern  string
+		version  string
+		change   string
+		expected string
+	}{
+		{"YYYY.MM.MICRO", fmt.Sprintf("%d.%d.3", year, month), "patch", fmt.Sprintf("%d.%d.4", year, month)},
+		{"YYYY.MM.MICRO", fmt.Sprintf("%d.%d.3", year, month), "minor", fmt.Sprintf("%d.%d.4", year, month)},
+		{"YYYY.MM.MICRO", fmt.Sprintf("%d.%d.3", year-1, month), "patch", fmt.Sprintf("%d.%d.0", year, month)},
+		{"YYYY.MM.MICRO", fmt.Sprintf("%d.%d.3-beta.1", year, month), "patch", fmt.Sprintf("%d.%d.3", year, month)},
+		{"YYYY.MM.MICRO", fmt.Sprintf("%d.%d.3-beta.1", year-1, month), "patch", fmt.Sprintf("%d.%d.0", year, month)},
+		{"YY.MINOR.MICRO", fmt.Sprintf("%d.2.3", shortYear), "minor", fmt.Sprintf("%d.3.0", shortYear)},
+		{"YY.MINOR.MICRO", fmt.Sprintf("%d.2.3", shortYear), "patch", fmt.Sprintf("%d.2.4", shortYear)},
+		{"YY.MINOR.MICRO", fmt.Sprintf("%d.2.3", shortYear-1), "minor", fmt.Sprintf("%d.0.0", shortYear)},
+		{"MAJOR.YYYY.MICRO", fmt.Sprintf("2.%d.3", year), "major", fmt.Sprintf("3.%d.0", year)},
+		{"MAJOR.YYYY.MICRO", fmt.Sprintf("2.%d.3", year-1), "patch", fmt.Sprintf("2.%d.0", year)},
+		{"MAJOR.YYYY.MICRO", fmt.Sprintf("2.%d.3", year-1), "major", fmt.Sprintf("3.%d.0", year)},
+		{"YYYY.MM", fmt.Sprintf("%d.%d", year-1, month), "patch", fmt.Sprintf("%d.%d", year, month)},
+	}
+
+	for _, tc := range cases {
+		scheme := newCalVerScheme(configuration.VersioningSettings{Scheme: "calver", Pattern: tc.pattern})
+		version, err := scheme.Parse(tc.version)
+
+		if err != nil {
+			t.Errorf("%s: cannot parse %s: %s", tc.pattern, tc.version, err.Error())
+			continue
+		}
+
+		if actual := scheme.Format(scheme.Increment(version, tc.change)); actual != tc.expected {
+			t.Errorf("%s: %s with %s: expected %s, got %s", tc.pattern, tc.version, tc.change, tc.expected, actual)
+		}
+	}
+}
+
+func TestCalVerFormatAndParse(t *testing.T) {
+	scheme := newCalVerScheme(configuration.VersioningSettings{Scheme: "calver", Pattern: "YY.0M.MICRO"})
+
+	if actual := scheme.Format(semver.MustParse("24.3.1-rc.0+build")); actual != "24.03.1-rc.0+build" {
+		t.Errorf("unexpected format: %s", actual)
+	}
+
+	if version, err := scheme.Parse("24.03.1"); err != nil || version.Minor() != 3 {
+		t.Errorf("unexpected version %v: %v", version, err)
+	}
+
+	if _, err := newCalVerScheme(configuration.VersioningSettings{Scheme: "calver", Pattern: "YYYY.MM"}).Parse("2024.3.1"); err == nil {
+		t.Error("expected an error for a version with more parts than the pattern")
+	}
+}
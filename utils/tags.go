@@ -0,0 +1,70 @@
+/*
+ * This file is part of impacca. Copyright (C) 2013 and above Shogun <shogun@cowtech.it>.
+ * Licensed under the MIT license, which can be found at https://choosealicense.com/licenses/mit.
+ */
+
+package utils
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/ShogunPanda/impacca/configuration"
+)
+
+const (
+	tagVersionPlaceholder = "{{version}}"
+	tagNamePlaceholder    = "{{name}}"
+	tagPathPlaceholder    = "{{path}}"
+)
+
+// Versions may be preceded by a dash, like in v-1.2.3
+var tagVersionMatcher = regexp.MustCompile("^-?(\\d.*)$")
+
+// currentTagTemplate returns the template of the GIT tags of the current package or repository.
+func currentTagTemplate() string {
+	if CurrentPackage != nil {
+		return CurrentPackage.TagTemplate()
+	} else if folder := goSubmoduleFolder(); folder != "" {
+		return folder + "/" + configuration.Current.TagTemplate
+	}
+
+	return configuration.Current.TagTemplate
+}
+
+func renderTagTemplate(template, version string, pkg *Package) string {
+	if strings.Count(template, tagVersionPlaceholder) != 1 {
+		Fatal("The tag template {errorPrimary}%s{-} must contain {errorPrimary}%s{-} exactly once.", template, tagVersionPlaceholder)
+	}
+
+	replacements := []string{tagVersionPlaceholder, version}
+
+	if pkg != nil {
+		replacements = append(replacements, tagNamePlaceholder, pkg.Name, tagPathPlaceholder, pkg.Path)
+	}
+
+	return strings.NewReplacer(replacements...).Replace(template)
+}
+
+// VersionTag returns the GIT tag of a version.
+func VersionTag(version string) string {
+	return renderTagTemplate(currentTagTemplate(), version, CurrentPackage)
+}
+
+// parseVersionTag returns the version of a GIT tag, if the tag is a version tag.
+func parseVersionTag(tag string) (string, bool) {
+	parts := strings.SplitN(VersionTag(tagVersionPlaceholder), tagVersionPlaceholder, 2)
+	prefix, suffix := parts[0], parts[1]
+
+	if len(tag) <= len(prefix)+len(suffix) || !strings.HasPrefix(tag, prefix) || !strings.HasSuffix(tag, suffix) {
+		return "", false
+	}
+
+	matches := tagVersionMatcher.FindStringSubmatch(tag[len(prefix) : len(tag)-len(suffix)])
+
+	if matches == nil {
+		return "", false
+	}
+
+	return matches[1], true
+}
@@ -0,0 +1,206 @@
+/*
+ * This file is part of impacca. Copyright (C) 2013 and above Shogun <shogun@cowtech.it>.
+ * Licensed under the MIT license, which can be found at https://choosealicense.com/licenses/mit.
+ */
+
+package utils
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/Masterminds/semver"
+	"github.com/ShogunPanda/impacca/configuration"
+)
+
+// ChangelogData is the data model available to changelog and release templates
+type ChangelogData struct {
+	Version         string
+	PreviousVersion string
+	Date            time.Time
+	Repository      string
+	RepositoryURL   string
+	CompareURL      string
+	Groups          []ChangesGroup
+	Changes         []Change
+	Authors         []string
+	repository      *Repository
+}
+
+var changelogPresets = map[string]string{
+	"impacca": `
+{{- if not .Date.IsZero}}### {{.Date.Format "2006-01-02"}} / {{.Version}}
+
+{{end}}
+{{- range $i, $group := .Groups}}
+	{{- if $group.Title}}{{if $i}}
+{{end}}#### {{$group.Title}}
+
+{{end}}
+	{{- range $group.Changes}}- {{entry $group .}}
+{{end}}
+{{- end}}`,
+	"conventional": `
+{{- if .CompareURL}}## [{{.Version}}]({{.CompareURL}}){{else}}## {{.Version}}{{end}}{{if not .Date.IsZero}} ({{.Date.Format "2006-01-02"}}){{end}}
+{{range .Groups}}
+### {{or .Title "Changes"}}
+
+{{range .Changes}}* {{if .Scope}}**{{.Scope}}:** {{end}}{{.Message}}{{if .Hash}} ({{link .Hash ($.CommitURL .Hash)}}){{end}}
+{{end}}
+{{- end}}`,
+	"minimal": "## {{.Version}}\n\n{{range .Changes}}- {{.Summary}}\n{{end}}",
+}
+
+var releasePresets = map[string]string{
+	"impacca": `
+{{- range $i, $group := .Groups}}
+	{{- if $group.Title}}{{if $i}}
+{{end}}### {{$group.Title}}
+
+{{end}}
+	{{- range $group.Changes}}- {{entry $group .}} ({{link .Hash ($.CommitURL .Hash)}})
+{{end}}
+{{- end}}`,
+	"conventional": `
+{{- range .Groups}}### {{or .Title "Changes"}}
+
+{{range .Changes}}* {{if .Scope}}**{{.Scope}}:** {{end}}{{.Message}} ({{link .Hash ($.CommitURL .Hash)}})
+{{end}}
+{{end}}
+{{- if .Authors}}### Contributors
+
+{{range .Authors}}- {{.}}
+{{end}}
+{{end}}
+{{- if .CompareURL}}**Full Changelog**: {{.CompareURL}}
+{{end}}`,
+	"minimal": "{{range .Changes}}- {{.Summary}}\n{{end}}",
+}
+
+var templatesCache = make(map[string]*template.Template)
+
+// NewChangelogData prepares the data model for changelog and release templates.
+// The previous version can be nil if the version is the first one.
+func NewChangelogData(repository *Repository, version, previousVersion *semver.Version, changes []Change, date time.Time) ChangelogData {
+	data := ChangelogData{Version: FormatVersion(version), Date: date, Authors: make([]string, 0), repository: repository}
+
+	if previousVersion != nil && previousVersion.String() != "0.0.0" {
+		data.PreviousVersion = FormatVersion(previousVersion)
+	}
+
+	if repository != nil {
+		data.Repository = repository.Path
+		data.RepositoryURL = repository.URL()
+
+		if data.PreviousVersion != "" {
+			data.CompareURL = repository.CompareURL(VersionTag(data.PreviousVersion), VersionTag(data.Version))
+		}
+	}
+
+	data.Groups = GroupChanges(changes)
+	data.Changes = make([]Change, 0)
+	seenAuthors := make(map[string]bool)
+
+	for _, group := range data.Groups {
+		data.Changes = append(data.Changes, group.Changes...)
+
+		for _, change := range group.Changes {
+			if change.Author != "" && !seenAuthors[change.Author] {
+				seenAuthors[change.Author] = true
+				data.Authors = append(data.Authors, change.Author)
+			}
+		}
+	}
+
+	return data
+}
+
+// CommitURL returns the URL of a commit, if the repository is known.
+func (d ChangelogData) CommitURL(hash string) string {
+	if d.repository == nil || hash == "" {
+		return ""
+	}
+
+	return d.repository.CommitURL(hash)
+}
+
+func loadTemplate(name string, settings configuration.TemplateSettings, presets map[string]string) *template.Template {
+	if cached, found := templatesCache[name]; found {
+		return cached
+	}
+
+	source := ""
+
+	if settings.Inline != "" {
+		source = settings.Inline
+	} else if settings.File != "" {
+		cwd, _ := os.Getwd()
+		path := settings.File
+
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(cwd, path)
+		}
+
+		rawSource, err := ioutil.ReadFile(path)
+
+		if err != nil {
+			Fatal("Cannot read the %s template file {errorPrimary}%s{-}: {errorPrimary}%s{-}", name, settings.File, err.Error())
+		}
+
+		source = string(rawSource)
+	} else {
+		preset := settings.Preset
+
+		if preset == "" {
+			preset = "impacca"
+		}
+
+		var found bool
+		if source, found = presets[preset]; !found {
+			Fatal("Unknown %s template preset {errorPrimary}%s{-}.", name, preset)
+		}
+	}
+
+	parsed, err := template.New(name).Funcs(template.FuncMap{
+		"entry": func(group ChangesGroup, change Change) string { return FormatChangeEntry(change, group.Title != "") },
+		"link": func(text, url string) string {
+			if url == "" {
+				return text
+			}
+
+			return fmt.Sprintf("[%s](%s)", text, url)
+		},
+	}).Parse(source)
+
+	if err != nil {
+		Fatal("Cannot parse the %s template: {errorPrimary}%s{-}", name, err.Error())
+	}
+
+	templatesCache[name] = parsed
+	return parsed
+}
+
+func executeTemplate(tmpl *template.Template, data ChangelogData) string {
+	var builder strings.Builder
+
+	if err := tmpl.Execute(&builder, data); err != nil {
+		Fatal("Cannot execute the {errorPrimary}%s{-} template: {errorPrimary}%s{-}", tmpl.Name(), err.Error())
+	}
+
+	return builder.String()
+}
+
+// RenderChangelogEntry renders a CHANGELOG.md entry using the configured template.
+func RenderChangelogEntry(data ChangelogData) string {
+	return executeTemplate(loadTemplate("changelog", configuration.Current.Templates.Changelog, changelogPresets), data)
+}
+
+// RenderReleaseBody renders a release body using the configured template.
+func RenderReleaseBody(data ChangelogData) string {
+	return executeTemplate(loadTemplate("release", configuration.Current.Templates.Release, releasePresets), data)
+}
@@ -0,0 +1,101 @@
+/*
+ * This file is part of impacca. Copyright (C) 2013 and above Shogun <shogun@cowtech.it>.
+ * Licensed under the MIT license, which can be found at https://choosealicense.com/licenses/mit.
+ */
+
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/Masterminds/semver"
+	"github.com/ShogunPanda/impacca/configuration"
+)
+
+func TestNewChangelogData(t *testing.T) {
+	changes := parseTestChanges("feat: Arrays", "fix: Crash", "Updated CHANGELOG.md.", "docs: Readme")
+	changes[0].Author, changes[1].Author, changes[2].Author, changes[3].Author = "John", "Jane", "Bot", "John"
+
+	cases := []struct {
+		repository      *Repository
+		previousVersion *semver.Version
+		compareURL      string
+		commitURL       string
+	}{
+		{testGitHubRepository, semver.MustParse("1.2.3"), "https://github.com/owner/repo/compare/v1.2.3...v1.3.0", "https://github.com/owner/repo/commit/abc"},
+		{testGitHubRepository, semver.MustParse("0.0.0"), "", "https://github.com/owner/repo/commit/abc"},
+		{testGitHubRepository, nil, "", "https://github.com/owner/repo/commit/abc"},
+		{
+			&Repository{Provider: &gitlabReleaseProvider{}, BaseURL: "https://gitlab.com", Path: "group/project"}, semver.MustParse("1.2.3"),
+			"https://gitlab.com/group/project/-/compare/v1.2.3...v1.3.0", "https://gitlab.com/group/project/-/commit/abc",
+		},
+		{nil, semver.MustParse("1.2.3"), "", ""},
+	}
+
+	for _, tc := range cases {
+		data := NewChangelogData(tc.repository, semver.MustParse("1.3.0"), tc.previousVersion, changes, time.Time{})
+
+		if data.Version != "1.3.0" || data.CompareURL != tc.compareURL {
+			t.Errorf("%v from %v: unexpected data %+v", tc.repository, tc.previousVersion, data)
+		}
+
+		if len(data.Changes) != 3 || !reflect.DeepEqual(data.Authors, []string{"John", "Jane"}) {
+			t.Errorf("unexpected changes %v by %v", data.Changes, data.Authors)
+		}
+
+		if commitURL := data.CommitURL("abc"); commitURL != tc.commitURL {
+			t.Errorf("%v: unexpected commit URL %s", tc.repository, commitURL)
+		}
+	}
+}
+
+func TestRenderChangelogEntry(t *testing.T) {
+	folder, err := ioutil.TempDir("", "impacca-templates")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(folder)
+
+	templateFile := filepath.Join(folder, "changelog.tmpl")
+	ioutil.WriteFile(templateFile, []byte("{{.Version}}:{{range .Changes}} {{.Hash}}{{end}}"), 0644)
+
+	previous := configuration.Current.Templates
+	defer func() {
+		configuration.Current.Templates = previous
+		templatesCache = make(map[string]*template.Template)
+	}()
+
+	changes := parseTestChanges("feat(lexer): Objects", "fix: Crash")
+	data := NewChangelogData(testGitHubRepository, semver.MustParse("1.3.0"), semver.MustParse("1.2.3"), changes, time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC))
+
+	cases := []struct {
+		settings configuration.TemplateSettings
+		expected string
+	}{
+		{configuration.TemplateSettings{}, "### 2019-01-02 / 1.3.0\n\n- feat(lexer): Objects\n- fix: Crash\n"},
+		{configuration.TemplateSettings{Preset: "minimal"}, "## 1.3.0\n\n- feat(lexer): Objects\n- fix: Crash\n"},
+		{
+			configuration.TemplateSettings{Preset: "conventional"},
+			"## [1.3.0](https://github.com/owner/repo/compare/v1.2.3...v1.3.0) (2019-01-02)\n\n### Changes\n\n" +
+				"* **lexer:** Objects ([a](https://github.com/owner/repo/commit/a))\n* Crash ([b](https://github.com/owner/repo/commit/b))\n",
+		},
+		{configuration.TemplateSettings{Preset: "minimal", Inline: "{{len .Changes}} changes by {{.Repository}}"}, "2 changes by owner/repo"},
+		{configuration.TemplateSettings{Preset: "minimal", File: templateFile}, "1.3.0: a b"},
+	}
+
+	for _, tc := range cases {
+		configuration.Current.Templates.Changelog = tc.settings
+		templatesCache = make(map[string]*template.Template)
+
+		if actual := RenderChangelogEntry(data); actual != tc.expected {
+			t.Errorf("%+v: unexpected entry:\n%s", tc.settings, actual)
+		}
+	}
+}
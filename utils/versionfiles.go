@@ -0,0 +1,419 @@
+/*
+ * This file is part of impacca. Copyright (C) 2013 and above Shogun <shogun@cowtech.it>.
+ * Licensed under the MIT license, which can be found at https://choosealicense.com/licenses/mit.
+ */
+
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/ShogunPanda/impacca/configuration"
+	"github.com/ShogunPanda/tempera"
+)
+
+// All editors work on the original text, so that formatting and comments are preserved.
+var quotedValueMatcher = regexp.MustCompile("^([\"'])(.*?)([\"'])(.*)$")
+var bareValueMatcher = regexp.MustCompile("^([^\\s#]+)(.*)$")
+var yamlKeyMatcher = regexp.MustCompile("^(\\s*)(\"[^\"]*\"|'[^']*'|[^\\s#:\"'][^:#]*?)\\s*:(\\s+|$)(.*)$")
+var tomlTableMatcher = regexp.MustCompile("^\\s*\\[\\[?\\s*([^\\]]+?)\\s*\\]\\]?\\s*(?:#.*)?$")
+var tomlKeyMatcher = regexp.MustCompile("^(\\s*)([A-Za-z0-9_\\-.\"' ]+?)\\s*=(\\s*)(.*)$")
+
+// replaceScalar replaces a YAML or TOML scalar value, preserving quotes and trailing comments.
+func replaceScalar(value, version string) string {
+	if matches := quotedValueMatcher.FindStringSubmatch(value); matches != nil {
+		return matches[1] + version + matches[3] + matches[4]
+	}
+
+	return bareValueMatcher.ReplaceAllString(value, version+"$2")
+}
+
+// normalizeKey removes quotes and spaces from a dotted key.
+func normalizeKey(key string) string {
+	parts := strings.Split(key, ".")
+
+	for i, part := range parts {
+		parts[i] = strings.Trim(strings.TrimSpace(part), "\"'")
+	}
+
+	return strings.Join(parts, ".")
+}
+
+func splitLines(contents string) ([]string, []string) {
+	lines := strings.SplitAfter(contents, "\n")
+	endings := make([]string, len(lines))
+
+	for i, line := range lines {
+		trimmed := strings.TrimRight(line, "\r\n")
+		endings[i] = line[len(trimmed):]
+		lines[i] = trimmed
+	}
+
+	return lines, endings
+}
+
+func joinLines(lines, endings []string) string {
+	var builder strings.Builder
+
+	for i, line := range lines {
+		builder.WriteString(line + endings[i])
+	}
+
+	return builder.String()
+}
+
+// replaceRegex replaces the first capture group (or the entire match if there are no groups) of all matches.
+func replaceRegex(contents, pattern, version string) (string, int, error) {
+	matcher, err := regexp.Compile(pattern)
+
+	if err != nil {
+		return "", 0, err
+	}
+
+	var builder strings.Builder
+	matches := matcher.FindAllStringSubmatchIndex(contents, -1)
+	last := 0
+
+	for _, indexes := range matches {
+		start, end := indexes[0], indexes[1]
+
+		if len(indexes) >= 4 && indexes[2] != -1 {
+			start, end = indexes[2], indexes[3]
+		}
+
+		builder.WriteString(contents[last:start] + version)
+		last = end
+	}
+
+	builder.WriteString(contents[last:])
+	return builder.String(), len(matches), nil
+}
+
+// jsonValueRanges scans a valid JSON document and returns the positions of all scalar values at a dotted path.
+// Array items are addressed by index, like files.0.version.
+func jsonValueRanges(contents, path string) [][2]int {
+	ranges := make([][2]int, 0)
+	position := 0
+
+	skipSpaces := func() {
+		for position < len(contents) && strings.ContainsRune(" \t\r\n", rune(contents[position])) {
+			position++
+		}
+	}
+
+	readString := func() string {
+		start := position
+		position++
+
+		for contents[position] != '"' {
+			if contents[position] == '\\' {
+				position++
+			}
+
+			position++
+		}
+
+		position++
+		unquoted, _ := strconv.Unquote(contents[start:position])
+		return unquoted
+	}
+
+	var readValue func(current string)
+	readValue = func(current string) {
+		skipSpaces()
+
+		switch contents[position] {
+		case '{', '[':
+			closing := byte('}')
+			if contents[position] == '[' {
+				closing = ']'
+			}
+
+			position++
+
+			for index := 0; ; index++ {
+				skipSpaces()
+
+				if contents[position] == closing {
+					position++
+					return
+				} else if contents[position] == ',' {
+					position++
+					skipSpaces()
+				}
+
+				key := strconv.Itoa(index)
+
+				if closing == '}' {
+					key = readString()
+					skipSpaces()
+					position++ // The colon
+				}
+
+				if current != "" {
+					key = current + "." + key
+				}
+
+				readValue(key)
+			}
+		case '"':
+			start := position
+			readString()
+
+			if current == path {
+				ranges = append(ranges, [2]int{start + 1, position - 1})
+			}
+		default:
+			start := position
+
+			for position < len(contents) && !strings.ContainsRune(",]} \t\r\n", rune(contents[position])) {
+				position++
+			}
+
+			if current == path {
+				ranges = append(ranges, [2]int{start, position})
+			}
+		}
+	}
+
+	readValue("")
+	return ranges
+}
+
+// replaceJSON replaces the values at a dotted path of a JSON file.
+func replaceJSON(contents, path, version string) (string, int, error) {
+	if !json.Valid([]byte(contents)) {
+		return "", 0, fmt.Errorf("invalid JSON")
+	}
+
+	ranges := jsonValueRanges(contents, path)
+
+	// Replace from the end so that positions are still valid
+	for i := len(ranges) - 1; i >= 0; i-- {
+		value := version
+
+		if contents[ranges[i][0]-1] != '"' {
+			value = strconv.Quote(version)
+		}
+
+		contents = contents[:ranges[i][0]] + value + contents[ranges[i][1]:]
+	}
+
+	return contents, len(ranges), nil
+}
+
+// replaceYAML replaces the values at a dotted path of a YAML file. Only block mappings are supported.
+func replaceYAML(contents, path, version string) (string, int, error) {
+	lines, endings := splitLines(contents)
+	count := 0
+
+	type yamlKey struct {
+		indentation int
+		name        string
+	}
+
+	stack := make([]yamlKey, 0)
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || trimmed == "---" {
+			continue
+		}
+
+		matches := yamlKeyMatcher.FindStringSubmatch(line)
+
+		if matches == nil {
+			continue
+		}
+
+		indentation := len(matches[1])
+		for len(stack) > 0 && stack[len(stack)-1].indentation >= indentation {
+			stack = stack[:len(stack)-1]
+		}
+
+		stack = append(stack, yamlKey{indentation, strings.Trim(matches[2], "\"'")})
+
+		names := make([]string, len(stack))
+		for j, key := range stack {
+			names[j] = key.name
+		}
+
+		if strings.Join(names, ".") == path && matches[4] != "" && !strings.HasPrefix(matches[4], "#") {
+			lines[i] = strings.TrimSuffix(line, matches[4]) + replaceScalar(matches[4], version)
+			count++
+		}
+	}
+
+	return joinLines(lines, endings), count, nil
+}
+
+// replaceTOML replaces the values of a key of a TOML file. Keys of tables are prefixed with the table name, like package.version.
+func replaceTOML(contents, key, version string) (string, int, error) {
+	lines, endings := splitLines(contents)
+	key = normalizeKey(key)
+	table := ""
+	count := 0
+
+	for i, line := range lines {
+		if matches := tomlTableMatcher.FindStringSubmatch(line); matches != nil {
+			table = normalizeKey(matches[1])
+			continue
+		}
+
+		matches := tomlKeyMatcher.FindStringSubmatch(line)
+
+		if matches == nil || strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+
+		current := normalizeKey(matches[2])
+
+		if table != "" {
+			current = table + "." + current
+		}
+
+		if current == key {
+			lines[i] = strings.TrimSuffix(line, matches[4]) + replaceScalar(matches[4], version)
+			count++
+		}
+	}
+
+	return joinLines(lines, endings), count, nil
+}
+
+// showDiff shows the lines changed in a file.
+func showDiff(file, previous, current string) {
+	previousLines, _ := splitLines(previous)
+	currentLines, _ := splitLines(current)
+
+	Log(os.Stdout, tempera.ColorizeTemplate("   {primary}--- %s{-}\n   {primary}+++ %s{-}\n"), file, file)
+
+	if len(previousLines) != len(currentLines) {
+		for _, line := range previousLines {
+			Log(os.Stdout, tempera.ColorizeTemplate("   {red}-%s{-}\n"), line)
+		}
+
+		for _, line := range currentLines {
+			Log(os.Stdout, tempera.ColorizeTemplate("   {green}+%s{-}\n"), line)
+		}
+
+		return
+	}
+
+	for i, line := range previousLines {
+		if line != currentLines[i] {
+			Log(os.Stdout, tempera.ColorizeTemplate("   {secondary}@@ line %d @@{-}\n"), i+1)
+			Log(os.Stdout, tempera.ColorizeTemplate("   {red}-%s{-}\n"), line)
+			Log(os.Stdout, tempera.ColorizeTemplate("   {green}+%s{-}\n"), currentLines[i])
+		}
+	}
+}
+
+func readTextFile(file string) string {
+	rawContents, err := ioutil.ReadFile(file)
+
+	if err != nil {
+		Fatal("Cannot read file {errorPrimary}%s{-}: {errorPrimary}%s{-}", file, err.Error())
+	}
+
+	return string(rawContents)
+}
+
+// writeUpdatedFile writes the updated contents of a file or, in dry-run mode, shows the differences.
+// It returns true if the file has been written.
+func writeUpdatedFile(file, relative, previous, updated string, dryRun bool) bool {
+	if !NotifyStep(dryRun, "", "Will update", "Updating", " the version in {primary}%s{-} ...", relative) {
+		showDiff(relative, previous, updated)
+		return false
+	}
+
+	if err := ioutil.WriteFile(file, []byte(updated), 0644); err != nil {
+		Fatal("Cannot update file {errorPrimary}%s{-}: {errorPrimary}%s{-}", relative, err.Error())
+	}
+
+	return true
+}
+
+func updateVersionFile(file string, settings configuration.VersionFile, version string) (string, string) {
+	rawContents, err := ioutil.ReadFile(file)
+
+	if err != nil {
+		Fatal("Cannot read version file {errorPrimary}%s{-}: {errorPrimary}%s{-}", file, err.Error())
+	}
+
+	contents := string(rawContents)
+	var updated string
+	var count int
+
+	switch {
+	case settings.Regex != "":
+		updated, count, err = replaceRegex(contents, settings.Regex, version)
+	case settings.JSON != "":
+		updated, count, err = replaceJSON(contents, settings.JSON, version)
+	case settings.YAML != "":
+		updated, count, err = replaceYAML(contents, settings.YAML, version)
+	case settings.TOML != "":
+		updated, count, err = replaceTOML(contents, settings.TOML, version)
+	default:
+		Fatal("The version file {errorPrimary}%s{-} must specify one of regex, json, yaml or toml.", settings.Glob)
+	}
+
+	if err != nil {
+		Fatal("Cannot update version file {errorPrimary}%s{-}: {errorPrimary}%s{-}", file, err.Error())
+	}
+
+	expected := settings.Count
+	if expected == 0 {
+		expected = 1
+	}
+
+	if count != expected {
+		Fatal(
+			"Cannot update version file {errorPrimary}%s{-}: expected {errorPrimary}%d{-} replacement(s), found {errorPrimary}%d{-}.",
+			file, expected, count,
+		)
+	}
+
+	return contents, updated
+}
+
+// UpdateVersionFiles updates the version in all configured version files and adds them to the GIT stage area.
+// It returns true if any file has been configured.
+func UpdateVersionFiles(newVersion *semver.Version, dryRun bool) bool {
+	versionFiles := configuration.Current.VersionFiles
+	version := FormatVersion(newVersion)
+	cwd, _ := os.Getwd()
+
+	for _, settings := range versionFiles {
+		files, err := filepath.Glob(filepath.Join(cwd, settings.Glob))
+
+		if err != nil {
+			Fatal("Invalid version files pattern {errorPrimary}%s{-}: {errorPrimary}%s{-}", settings.Glob, err.Error())
+		} else if len(files) == 0 {
+			Fatal("No version files match the pattern {errorPrimary}%s{-}.", settings.Glob)
+		}
+
+		for _, file := range files {
+			relative, _ := filepath.Rel(cwd, file)
+			previous, updated := updateVersionFile(file, settings, version)
+
+			if !writeUpdatedFile(file, relative, previous, updated, dryRun) {
+				continue
+			}
+
+			result := Execute(false, "git", "add", file)
+			result.Verify("git", "Cannot add version files to git stage area")
+		}
+	}
+
+	return len(versionFiles) > 0
+}
@@ -0,0 +1,103 @@
+/*
+ * This file is part of impacca. Copyright (C) 2013 and above Shogun <shogun@cowtech.it>.
+ * Licensed under the MIT license, which can be found at https://choosealicense.com/licenses/mit.
+ */
+
+package utils
+
+import (
+	"testing"
+)
+
+type versionReplacerCase struct {
+	name     string
+	contents string
+	path     string
+	expected string
+	count    int
+}
+
+func testVersionReplacer(t *testing.T, replacer func(contents, path, version string) (string, int, error), cases []versionReplacerCase) {
+	t.Helper()
+
+	for _, tc := range cases {
+		actual, count, err := replacer(tc.contents, tc.path, "1.3.0")
+
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", tc.name, err.Error())
+		} else if actual != tc.expected || count != tc.count {
+			t.Errorf("%s: expected %d replacements in:\n%s\ngot %d replacements in:\n%s", tc.name, tc.count, tc.expected, count, actual)
+		}
+	}
+}
+
+func TestReplaceJSON(t *testing.T) {
+	testVersionReplacer(t, replaceJSON, []versionReplacerCase{
+		{
+			"top level", "{\n  \"name\": \"app\",\n  \"version\": \"1.2.3\"\n}\n", "version",
+			"{\n  \"name\": \"app\",\n  \"version\": \"1.3.0\"\n}\n", 1,
+		},
+		{
+			"nested with escapes", `{"a": {"b\"c": {"version": "1.2.3"}}, "version": "0.0.1"}`, `a.b"c.version`,
+			`{"a": {"b\"c": {"version": "1.3.0"}}, "version": "0.0.1"}`, 1,
+		},
+		{
+			"array items", `{"files": [{"version": "1.2.3"}, {"version": "1.2.3"}]}`, "files.1.version",
+			`{"files": [{"version": "1.2.3"}, {"version": "1.3.0"}]}`, 1,
+		},
+		{
+			"non string value", `{"version":null,"other":true}`, "version", `{"version":"1.3.0","other":true}`, 1,
+		},
+		{
+			"missing path", `{"version": "1.2.3"}`, "package.version", `{"version": "1.2.3"}`, 0,
+		},
+	})
+
+	if _, _, err := replaceJSON(`{"version": }`, "version", "1.3.0"); err == nil {
+		t.Error("expected an error for invalid JSON")
+	}
+}
+
+func TestReplaceYAML(t *testing.T) {
+	testVersionReplacer(t, replaceYAML, []versionReplacerCase{
+		{
+			"top level", "name: app\nversion: 1.2.3 # The version\n", "version", "name: app\nversion: 1.3.0 # The version\n", 1,
+		},
+		{
+			"nested and quoted", "---\napp:\n  image:\n    tag: \"1.2.3\"\n  tag: 'latest'\ntag: 1.2.3\r\n", "app.image.tag",
+			"---\napp:\n  image:\n    tag: \"1.3.0\"\n  tag: 'latest'\ntag: 1.2.3\r\n", 1,
+		},
+		{
+			"mapping value", "version:\n  number: 1.2.3\n", "version", "version:\n  number: 1.2.3\n", 0,
+		},
+		{
+			"comments", "# version: 1.2.3\nversion: '1.2.3'\n", "version", "# version: 1.2.3\nversion: '1.3.0'\n", 1,
+		},
+	})
+}
+
+func TestReplaceTOML(t *testing.T) {
+	testVersionReplacer(t, replaceTOML, []versionReplacerCase{
+		{
+			"table", "[package]\nname = \"app\"\nversion = \"1.2.3\" # The version\n\n[dependencies]\nversion = \"1.0\"\n", "package.version",
+			"[package]\nname = \"app\"\nversion = \"1.3.0\" # The version\n\n[dependencies]\nversion = \"1.0\"\n", 1,
+		},
+		{
+			"nested table", "[tool.poetry]\nversion = '1.2.3'\n", "tool.poetry.version", "[tool.poetry]\nversion = '1.3.0'\n", 1,
+		},
+		{
+			"dotted key", "tool.poetry.version = \"1.2.3\"\n", "tool.poetry.version", "tool.poetry.version = \"1.3.0\"\n", 1,
+		},
+		{
+			"top level and comments", "# version = \"1.2.3\"\nversion = \"1.2.3\"\n[package]\nversion = \"1.2.3\"\n", "version",
+			"# version = \"1.2.3\"\nversion = \"1.3.0\"\n[package]\nversion = \"1.2.3\"\n", 1,
+		},
+	})
+}
+
+func TestReplaceRegex(t *testing.T) {
+	testVersionReplacer(t, replaceRegex, []versionReplacerCase{
+		{"group", "VERSION = '1.2.3'\nOTHER = '1.2.3'\n", "VERSION = '([^']+)'", "VERSION = '1.3.0'\nOTHER = '1.2.3'\n", 1},
+		{"whole match", "v1.2.3 and v1.2.4", "\\d+\\.\\d+\\.\\d+", "v1.3.0 and v1.3.0", 2},
+	})
+}
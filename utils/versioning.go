@@ -7,11 +7,11 @@ package utils
 
 import (
 	"fmt"
-	"io/ioutil"
 	"os"
 	"path/filepath"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -19,8 +19,10 @@ import (
 	"github.com/ShogunPanda/impacca/configuration"
 )
 
+var prereleaseCounterMatcher = regexp.MustCompile("\\.?\\d+$")
+
 func commitVersioning(version *semver.Version, commit, tag, dryRun bool) {
-	versionString := version.String()
+	versionString := FormatVersion(version)
 	versionMessage := strings.TrimSpace(fmt.Sprintf(configuration.Current.CommitMessages.Versioning, versionString))
 
 	// Commit changes
@@ -31,8 +33,9 @@ func commitVersioning(version *semver.Version, commit, tag, dryRun bool) {
 	}
 
 	// Tag the version
-	if tag && NotifyExecution(dryRun, "Will execute", "Executing", ": {primary}git tag -f v%s{-} ...", versionString) {
-		result := Execute(true, "git", "tag", "--force", "v"+versionString)
+	tagName := VersionTag(versionString)
+	if tag && NotifyExecution(dryRun, "Will execute", "Executing", ": {primary}git tag -f %s{-} ...", tagName) {
+		result := Execute(true, "git", "tag", "--force", tagName)
 		result.Verify("git", "Cannot tag GIT version")
 	}
 }
@@ -44,11 +47,13 @@ func GetVersions() semver.Collection {
 
 	var versions semver.Collection
 	for _, tag := range strings.Split(strings.TrimSpace(result.Stdout), "\n") {
-		if !versionMatcher.MatchString(tag) {
+		rawVersion, isVersion := parseVersionTag(tag)
+
+		if !isVersion {
 			continue
 		}
 
-		version, err := semver.NewVersion(versionMatcher.ReplaceAllString(tag, ""))
+		version, err := ParseVersion(rawVersion)
 
 		if err != nil {
 			Fail("Cannot parse GIT tag {errorPrimary}%s{-} as a version, will skip it: {errorPrimary}%s{-}", tag, err.Error())
@@ -64,14 +69,31 @@ func GetVersions() semver.Collection {
 	return versions
 }
 
+// initialVersion returns the version used when there are no versions yet.
+func initialVersion() *semver.Version {
+	version, _ := semver.NewVersion("0.0.0")
+	return version
+}
+
 // GetCurrentVersion return the current version.
 func GetCurrentVersion() *semver.Version {
 	// Get the current version
 	versions := GetVersions()
 
+	if configuration.Current.Bumping.IgnorePrereleases {
+		stableVersions := make(semver.Collection, 0, len(versions))
+
+		for _, version := range versions {
+			if version.Prerelease() == "" {
+				stableVersions = append(stableVersions, version)
+			}
+		}
+
+		versions = stableVersions
+	}
+
 	if len(versions) == 0 {
-		version, _ := semver.NewVersion("0.0.0")
-		return version
+		return initialVersion()
 	}
 
 	return versions[len(versions)-1]
@@ -79,7 +101,7 @@ func GetCurrentVersion() *semver.Version {
 
 // GetVersionDate return the date of a version.
 func GetVersionDate(version *semver.Version) time.Time {
-	result := Execute(false, "git", "log", "--format=%aI", "-n 1", fmt.Sprintf("v%s", version.String()))
+	result := Execute(false, "git", "log", "--format=%aI", "-n 1", VersionTag(FormatVersion(version)))
 	result.Verify("git", "Cannot list GIT commits date")
 
 	date, err := time.Parse(time.RFC3339, strings.TrimSpace(result.Stdout))
@@ -91,20 +113,67 @@ func GetVersionDate(version *semver.Version) time.Time {
 	return date
 }
 
-// ChangeVersion changes the current version.
-func ChangeVersion(version *semver.Version, change string) *semver.Version {
+// PrereleaseIdentifier returns the prerelease identifier of a version, like beta for 1.2.3-beta.4.
+func PrereleaseIdentifier(version *semver.Version) string {
+	return prereleaseCounterMatcher.ReplaceAllString(version.Prerelease(), "")
+}
+
+// prereleaseVersion returns the first prerelease of a version with a given identifier which has not been tagged yet.
+func prereleaseVersion(base semver.Version, preid string, current *semver.Version) *semver.Version {
+	prefix := ""
+
+	if preid != "" {
+		prefix = preid + "."
+	}
+
+	counter := 0
+
+	for _, existing := range append(GetVersions(), current) {
+		if existing.Major() != base.Major() || existing.Minor() != base.Minor() || existing.Patch() != base.Patch() {
+			continue
+		} else if !strings.HasPrefix(existing.Prerelease(), prefix) {
+			continue
+		}
+
+		if existingCounter, err := strconv.Atoi(strings.TrimPrefix(existing.Prerelease(), prefix)); err == nil && existingCounter >= counter {
+			counter = existingCounter + 1
+		}
+	}
+
+	newVersion, err := base.SetPrerelease(fmt.Sprintf("%s%d", prefix, counter))
+
+	if err != nil {
+		Fatal("Cannot use {errorPrimary}%s{-} as prerelease identifier: {errorPrimary}%s{-}", preid, err.Error())
+	}
+
+	return &newVersion
+}
+
+// ChangeVersion changes the current version using the configured versioning scheme.
+// Prerelease changes use the preid identifier and the first counter not already tagged, like 1.3.0-beta.0.
+func ChangeVersion(version *semver.Version, change, preid string) *semver.Version {
+	scheme := CurrentVersioningScheme()
+	stable, _ := version.SetPrerelease("")
 	newVersion := &semver.Version{}
 	var err error
 
 	switch change {
-	case "patch":
-		*newVersion = version.IncPatch()
-	case "minor":
-		*newVersion = version.IncMinor()
-	case "major":
-		*newVersion = version.IncMajor()
+	case "patch", "minor", "major":
+		newVersion = scheme.Increment(version, change)
+	case "prepatch", "preminor", "premajor":
+		newVersion = prereleaseVersion(*scheme.Increment(&stable, strings.TrimPrefix(change, "pre")), preid, version)
+	case "prerelease":
+		if version.Prerelease() == "" {
+			newVersion = prereleaseVersion(*scheme.Increment(&stable, "patch"), preid, version)
+		} else {
+			if preid == "" {
+				preid = PrereleaseIdentifier(version)
+			}
+
+			newVersion = prereleaseVersion(stable, preid, version)
+		}
 	default:
-		newVersion, err = semver.NewVersion(change)
+		newVersion, err = scheme.Parse(change)
 
 		if err != nil {
 			Fatal("Cannot parse {errorPrimary}%s{-} as a version: {errorPrimary}%s{-}", change, err.Error())
@@ -114,87 +183,79 @@ func ChangeVersion(version *semver.Version, change string) *semver.Version {
 	return newVersion
 }
 
-// UpdateVersion updates the current version.
-func UpdateVersion(newVersion, currentVersion *semver.Version, dryRun bool) {
-	switch DetectPackageManager() {
-	case NpmPackageManager:
-		UpdateNpmVersion(newVersion, currentVersion, true, true, dryRun)
-	case GemPackageManager:
-		UpdateGemVersion(newVersion, currentVersion, true, true, dryRun)
-	default:
-		UpdatePlainVersion(newVersion, currentVersion, true, true, dryRun)
-	}
-}
+// ReleaseVersion updates the version of the package, commits the changes and tags the new version.
+func ReleaseVersion(manager PackageManager, newVersion, currentVersion *semver.Version, dryRun bool) {
+	manager.SetVersion(newVersion, currentVersion, dryRun)
+	files := manager.Files()
 
-// UpdateNpmVersion updates the current version using NPM.
-func UpdateNpmVersion(newVersion, currentVersion *semver.Version, commit, tag, dryRun bool) {
-	versionString := newVersion.String()
-	versionMessage := strings.TrimSpace(configuration.Current.CommitMessages.Versioning)
-
-	if !NotifyExecution(dryRun, "Will execute", "Executing", ": {primary}npm version %s --message=%s{-} ...", versionString, versionMessage) {
-		return
+	if len(files) > 0 && !dryRun {
+		result := Execute(false, "git", append([]string{"add", "--"}, files...)...)
+		result.Verify("git", "Cannot add version changes to git stage area")
 	}
 
-	result := Execute(true, "npm", "version", versionString, fmt.Sprintf("--message=%s", versionMessage))
-	result.Verify("npm", "Cannot update NPM version")
-}
+	// Changes to version files and changes made by the Impaccafile are committed together
+	changed := UpdateVersionFiles(newVersion, dryRun) || len(files) > 0
+	changed = runImpaccafile(newVersion, currentVersion, dryRun) || changed
 
-// UpdateGemVersion updates the current version by manipulating the version file.
-func UpdateGemVersion(newVersion, currentVersion *semver.Version, commit, tag, dryRun bool) {
-	cwd, _ := os.Getwd()
-	files, _ := filepath.Glob(filepath.Join(cwd, "*/*/version.rb"))
+	commitVersioning(newVersion, changed, true, dryRun)
+}
 
-	if len(files) != 1 {
-		Fatal("Found no or more than one possible gem version files.")
+// PrepareDevelopmentVersion switches the package to a development version after a release, if the package manager supports it.
+func PrepareDevelopmentVersion(manager PackageManager, releasedVersion *semver.Version, dryRun bool) {
+	if developer, supported := manager.(DevelopmentPackageManager); supported {
+		developer.PrepareDevelopmentVersion(releasedVersion, dryRun)
 	}
+}
 
-	// Open the version file
-	versionFile := files[0]
-	rawVersionContents, err := ioutil.ReadFile(versionFile)
+// UpdateVersion updates the current version.
+func UpdateVersion(newVersion, currentVersion *semver.Version, dryRun bool) {
+	manager := DetectPackageManager()
 
-	if err != nil {
-		Fatal("Cannot read gem version file {errorPrimary}%s{-}: {errorPrimary}%s{-}", versionFile, err.Error())
-	}
+	ReleaseVersion(manager, newVersion, currentVersion, dryRun)
+	PrepareDevelopmentVersion(manager, newVersion, dryRun)
+}
 
-	if !dryRun {
-		versionContents := string(rawVersionContents)
+// runImpaccafile executes the Impaccafile script, if any. It returns true if the script exists.
+func runImpaccafile(newVersion, currentVersion *semver.Version, dryRun bool) bool {
+	versionString := FormatVersion(newVersion)
 
-		// Replace contents
-		versionContents = regexp.MustCompile("(?m)^(?:(\\s*MAJOR)\\s*=\\s*\\d+)$").ReplaceAllString(versionContents, fmt.Sprintf("$1 = %d", newVersion.Major()))
-		versionContents = regexp.MustCompile("(?m)^(?:(\\s*MINOR)\\s*=\\s*\\d+)$").ReplaceAllString(versionContents, fmt.Sprintf("$1 = %d", newVersion.Minor()))
-		versionContents = regexp.MustCompile("(?m)^(?:(\\s*PATCH)\\s*=\\s*\\d+)$").ReplaceAllString(versionContents, fmt.Sprintf("$1 = %d", newVersion.Patch()))
+	cwd, _ := os.Getwd()
+	stat, err := os.Stat(filepath.Join(cwd, "Impaccafile"))
 
-		err := ioutil.WriteFile(versionFile, []byte(versionContents), 0644)
+	if err != nil || stat.IsDir() || stat.Mode()&0111 == 0 {
+		return false
+	}
 
-		if err != nil {
-			Fatal("Cannot update gem version file {errorPrimary}%s{-}: {errorPrimary}%s{-}", versionFile, err.Error())
-		}
+	if NotifyExecution(dryRun, "Will execute", "Executing", ": {primary}./Impaccafile %s %s{-} ...", versionString, FormatVersion(currentVersion)) {
+		result := Execute(true, filepath.Join(cwd, "Impaccafile"), versionString, FormatVersion(currentVersion))
+		result.Verify("git", "Cannot execute the Impaccafile")
 	}
 
-	commitVersioning(newVersion, commit, tag, dryRun)
+	return true
 }
 
-// UpdatePlainVersion updates the current version according to a plain managament.
-func UpdatePlainVersion(newVersion, currentVersion *semver.Version, commit, tag, dryRun bool) {
-	versionString := newVersion.String()
-	versionMessage := strings.TrimSpace(fmt.Sprintf(configuration.Current.CommitMessages.Versioning, versionString))
+// plainPackageManager releases using Git
+type plainPackageManager struct {
+	changedFiles
+}
 
-	cwd, _ := os.Getwd()
-	stat, err := os.Stat(filepath.Join(cwd, "Impaccafile"))
+func (m *plainPackageManager) Name() string {
+	return "plain"
+}
 
-	if err == nil && stat.IsDir() == false && stat.Mode()&0111 != 0 {
-		if NotifyExecution(dryRun, "Will execute", "Executing", ": {primary}./Impaccafile %s %s{-} ...", newVersion, currentVersion) {
-			result := Execute(true, filepath.Join(cwd, "Impaccafile"), versionString, currentVersion.String())
-			result.Verify("git", "Cannot execute the Impaccafile")
-		}
+func (m *plainPackageManager) Detect(folder string) bool {
+	return true
+}
 
-		if commit {
-			if NotifyExecution(dryRun, "Will execute", "Executing", ": {primary}git commit --all --message \"%s\"{-} ...", versionMessage) {
-				result := Execute(true, "git", "commit", "--all", fmt.Sprintf("--message=%s", versionMessage))
-				result.Verify("Impaccafile", "Cannot commit Impaccafile changes")
-			}
-		}
-	}
+func (m *plainPackageManager) CurrentVersion() string {
+	return ""
+}
+
+// SetVersion does nothing as the version is only tracked by GIT tags and version files.
+func (m *plainPackageManager) SetVersion(newVersion, currentVersion *semver.Version, dryRun bool) {
+	m.resetFiles()
+}
 
-	commitVersioning(newVersion, false, tag, dryRun)
+// Publish does nothing as GIT tags are pushed after publishing.
+func (m *plainPackageManager) Publish(version *semver.Version, options PublishOptions) {
 }
@@ -0,0 +1,69 @@
+/*
+ * This file is part of impacca. Copyright (C) 2013 and above Shogun <shogun@cowtech.it>.
+ * Licensed under the MIT license, which can be found at https://choosealicense.com/licenses/mit.
+ */
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/Masterminds/semver"
+)
+
+func TestChangeVersion(t *testing.T) {
+	done := useTestGitRepository(t, "v1.2.0", "v1.3.0-beta.0", "v1.3.0-beta.1")
+	defer done()
+
+	cases := []struct {
+		version  string
+		change   string
+		preid    string
+		expected string
+	}{
+		{"1.2.0", "patch", "", "1.2.1"},
+		{"1.2.0", "minor", "", "1.3.0"},
+		{"1.2.0", "major", "", "2.0.0"},
+		{"1.2.0", "prepatch", "", "1.2.1-0"},
+		{"1.2.0", "preminor", "beta", "1.3.0-beta.2"},
+		{"1.2.0", "preminor", "alpha", "1.3.0-alpha.0"},
+		{"1.2.0", "premajor", "rc", "2.0.0-rc.0"},
+		{"1.2.0", "prerelease", "beta", "1.2.1-beta.0"},
+		{"1.3.0-beta.1", "prerelease", "", "1.3.0-beta.2"},
+		{"1.3.0-beta.1", "prerelease", "rc", "1.3.0-rc.0"},
+		{"1.3.0-beta.1", "minor", "", "1.3.0"},
+		{"1.3.0-beta.1", "2.0.0", "", "2.0.0"},
+	}
+
+	for _, tc := range cases {
+		version := semver.MustParse(tc.version)
+
+		if actual := FormatVersion(ChangeVersion(version, tc.change, tc.preid)); actual != tc.expected {
+			t.Errorf("%s with %s and preid %q: expected %s, got %s", tc.version, tc.change, tc.preid, tc.expected, actual)
+		}
+	}
+}
+
+func TestPrereleaseChange(t *testing.T) {
+	cases := []struct {
+		version  string
+		bump     string
+		expected string
+	}{
+		{"1.2.0", PatchBump, "prepatch"},
+		{"1.2.0", MinorBump, "preminor"},
+		{"1.2.0", MajorBump, "premajor"},
+		{"1.2.1-beta.0", PatchBump, "prerelease"},
+		{"1.2.1-beta.0", MinorBump, "preminor"},
+		{"1.3.0-beta.0", MinorBump, "prerelease"},
+		{"1.3.0-beta.0", MajorBump, "premajor"},
+		{"2.0.0-beta.0", MinorBump, "prerelease"},
+		{"2.0.0-beta.0", MajorBump, "prerelease"},
+	}
+
+	for _, tc := range cases {
+		if actual := PrereleaseChange(semver.MustParse(tc.version), tc.bump); actual != tc.expected {
+			t.Errorf("%s with %s: expected %s, got %s", tc.version, tc.bump, tc.expected, actual)
+		}
+	}
+}
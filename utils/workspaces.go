@@ -0,0 +1,288 @@
+/*
+ * This file is part of impacca. Copyright (C) 2013 and above Shogun <shogun@cowtech.it>.
+ * Licensed under the MIT license, which can be found at https://choosealicense.com/licenses/mit.
+ */
+
+package utils
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ShogunPanda/impacca/configuration"
+)
+
+const (
+	// AtTagStyle tags packages versions as name@1.2.3
+	AtTagStyle = "at"
+	// PathTagStyle tags packages versions as path/v1.2.3
+	PathTagStyle = "path"
+)
+
+var goModuleMatcher = regexp.MustCompile("(?m)^module\\s+(\\S+)")
+var goRequireMatcher = regexp.MustCompile("(?m)^(?:require\\s+|\\s+)([^\\s()]+)\\s+v\\S+")
+var ignoredWorkspaceFolders = map[string]bool{".git": true, "node_modules": true, "vendor": true, "testdata": true}
+
+type npmWorkspacesJSON struct {
+	Name                 string            `json:"name"`
+	Workspaces           json.RawMessage   `json:"workspaces"`
+	Dependencies         map[string]string `json:"dependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+}
+
+// Package represents a package of a monorepo.
+// Dependencies only contains runtime dependencies, as packages often have development or peer dependencies on each other.
+type Package struct {
+	Name         string
+	Path         string
+	Manager      PackageManager
+	TagStyle     string
+	Dependencies []string
+}
+
+// CurrentPackage is the monorepo package commands are operating on, if any
+var CurrentPackage *Package
+
+var repositoryRoot string
+
+// TagTemplate returns the template of all GIT tags of the package.
+// A configured tag template referencing the package name or path is used for all packages.
+func (p *Package) TagTemplate() string {
+	template := configuration.Current.TagTemplate
+
+	if strings.Contains(template, tagNamePlaceholder) || strings.Contains(template, tagPathPlaceholder) {
+		return template
+	} else if p.TagStyle == AtTagStyle {
+		return tagNamePlaceholder + "@" + tagVersionPlaceholder
+	} else if p.Path == "." {
+		return template
+	}
+
+	return tagPathPlaceholder + "/" + template
+}
+
+// Tag returns the GIT tag of a package version.
+func (p *Package) Tag(version string) string {
+	return renderTagTemplate(p.TagTemplate(), version, p)
+}
+
+// IsMonorepo checks if the monorepo support is enabled.
+func IsMonorepo() bool {
+	return configuration.Current.Monorepo.Enabled
+}
+
+// GetRepositoryRoot returns the root folder of the GIT repository.
+func GetRepositoryRoot() string {
+	if repositoryRoot == "" {
+		result := Execute(false, "git", "rev-parse", "--show-toplevel")
+		result.Verify("git", "Cannot detect the GIT repository root")
+
+		repositoryRoot = strings.TrimSpace(result.Stdout)
+	}
+
+	return repositoryRoot
+}
+
+func readNpmWorkspacesJSON(folder string) (*npmWorkspacesJSON, bool) {
+	rawPackage, err := ioutil.ReadFile(filepath.Join(folder, "package.json"))
+
+	if err != nil {
+		return nil, false
+	}
+
+	var parsed npmWorkspacesJSON
+	if err := json.Unmarshal(rawPackage, &parsed); err != nil {
+		Warn("The file {secondary}%s{-} is not a valid JSON file. Ignoring it.", filepath.Join(folder, "package.json"))
+		return nil, false
+	}
+
+	return &parsed, true
+}
+
+// npmWorkspaces returns the workspaces globs of the root package.json, if any.
+func npmWorkspaces(root string) []string {
+	parsed, found := readNpmWorkspacesJSON(root)
+
+	if !found || len(parsed.Workspaces) == 0 {
+		return []string{}
+	}
+
+	// Workspaces can either be a list or an object with the packages key
+	var workspaces []string
+	if err := json.Unmarshal(parsed.Workspaces, &workspaces); err == nil {
+		return workspaces
+	}
+
+	var nested struct {
+		Packages []string `json:"packages"`
+	}
+
+	json.Unmarshal(parsed.Workspaces, &nested)
+	return nested.Packages
+}
+
+// goModules returns all folders containing a go.mod file.
+func goModules(root string) []string {
+	modules := make([]string, 0)
+
+	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+
+		if info.IsDir() && path != root && (ignoredWorkspaceFolders[info.Name()] || strings.HasPrefix(info.Name(), ".")) {
+			return filepath.SkipDir
+		}
+
+		if !info.IsDir() && info.Name() == "go.mod" {
+			relative, _ := filepath.Rel(root, filepath.Dir(path))
+			modules = append(modules, relative)
+		}
+
+		return nil
+	})
+
+	return modules
+}
+
+func loadPackage(root, relative string) *Package {
+	folder := filepath.Join(root, relative)
+	pkg := &Package{Name: relative, Path: filepath.ToSlash(relative), Manager: detectPackageManager(folder), Dependencies: make([]string, 0)}
+
+	if rawModule, err := ioutil.ReadFile(filepath.Join(folder, "go.mod")); err == nil {
+		pkg.TagStyle = PathTagStyle
+
+		if matches := goModuleMatcher.FindSubmatch(rawModule); matches != nil {
+			pkg.Name = string(matches[1])
+		}
+
+		for _, matches := range goRequireMatcher.FindAllSubmatch(rawModule, -1) {
+			pkg.Dependencies = append(pkg.Dependencies, string(matches[1]))
+		}
+	} else if parsed, found := readNpmWorkspacesJSON(folder); found {
+		pkg.TagStyle = AtTagStyle
+
+		if parsed.Name != "" {
+			pkg.Name = parsed.Name
+		}
+
+		for _, dependencies := range []map[string]string{parsed.Dependencies, parsed.OptionalDependencies} {
+			for name := range dependencies {
+				pkg.Dependencies = append(pkg.Dependencies, name)
+			}
+		}
+	} else {
+		pkg.TagStyle = AtTagStyle
+	}
+
+	if style := configuration.Current.Monorepo.TagStyle; style != "" {
+		pkg.TagStyle = style
+	}
+
+	return pkg
+}
+
+// DiscoverPackages finds all the packages of a monorepo, either using the configured globs or
+// by looking for npm workspaces and go.mod files.
+func DiscoverPackages() []*Package {
+	root := GetRepositoryRoot()
+	globs := configuration.Current.Monorepo.Packages
+	folders := make([]string, 0)
+
+	if len(globs) == 0 {
+		globs = npmWorkspaces(root)
+		folders = append(folders, goModules(root)...)
+	}
+
+	for _, glob := range globs {
+		matches, err := filepath.Glob(filepath.Join(root, glob))
+
+		if err != nil {
+			Fatal("Invalid packages pattern {errorPrimary}%s{-}: {errorPrimary}%s{-}", glob, err.Error())
+		}
+
+		for _, match := range matches {
+			if info, err := os.Stat(match); err == nil && info.IsDir() {
+				relative, _ := filepath.Rel(root, match)
+				folders = append(folders, relative)
+			}
+		}
+	}
+
+	packages := make([]*Package, 0)
+	seen := make(map[string]bool)
+
+	for _, folder := range folders {
+		if !seen[folder] {
+			seen[folder] = true
+			packages = append(packages, loadPackage(root, folder))
+		}
+	}
+
+	sort.SliceStable(packages, func(i, j int) bool { return packages[i].Path < packages[j].Path })
+	return packages
+}
+
+// FindPackage finds a package by name or path.
+func FindPackage(packages []*Package, name string) *Package {
+	name = strings.TrimSuffix(filepath.ToSlash(name), "/")
+
+	for _, pkg := range packages {
+		if pkg.Name == name || pkg.Path == name {
+			return pkg
+		}
+	}
+
+	return nil
+}
+
+// SortPackages sorts packages so that each package comes after the workspace packages it depends on at runtime.
+func SortPackages(packages []*Package) []*Package {
+	sorted := make([]*Package, 0, len(packages))
+	states := make(map[*Package]int) // 1 = visiting, 2 = visited
+
+	var visit func(pkg *Package, chain []string)
+	visit = func(pkg *Package, chain []string) {
+		chain = append(chain, pkg.Name)
+
+		switch states[pkg] {
+		case 1:
+			Fatal("Circular dependency between packages: {errorPrimary}%s{-}.", strings.Join(chain, " -> "))
+		case 2:
+			return
+		}
+
+		states[pkg] = 1
+
+		for _, dependency := range pkg.Dependencies {
+			for _, other := range packages {
+				if other != pkg && other.Name == dependency {
+					visit(other, chain)
+				}
+			}
+		}
+
+		states[pkg] = 2
+		sorted = append(sorted, pkg)
+	}
+
+	for _, pkg := range packages {
+		visit(pkg, []string{})
+	}
+
+	return sorted
+}
+
+// SelectPackage makes all subsequent operations work on a package, changing the current folder to the package folder.
+func SelectPackage(pkg *Package) {
+	if err := os.Chdir(filepath.Join(GetRepositoryRoot(), pkg.Path)); err != nil {
+		Fatal("Cannot switch to package {errorPrimary}%s{-}: {errorPrimary}%s{-}", pkg.Name, err.Error())
+	}
+
+	CurrentPackage = pkg
+}
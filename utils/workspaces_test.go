@@ -0,0 +1,73 @@
+/*
+ * This file is part of impacca. Copyright (C) 2013 and above Shogun <shogun@cowtech.it>.
+ * Licensed under the MIT license, which can be found at https://choosealicense.com/licenses/mit.
+ */
+
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestLoadPackageIgnoresDevelopmentDependencies(t *testing.T) {
+	root, err := ioutil.TempDir("", "impacca-workspaces")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(root)
+
+	os.MkdirAll(filepath.Join(root, "packages", "a"), 0755)
+	ioutil.WriteFile(filepath.Join(root, "packages", "a", "package.json"), []byte(`{
+		"name": "a", "dependencies": {"b": "^1.0.0"}, "optionalDependencies": {"c": "^1.0.0"},
+		"devDependencies": {"d": "^1.0.0"}, "peerDependencies": {"e": "^1.0.0"}
+	}`), 0644)
+
+	pkg := loadPackage(root, filepath.Join("packages", "a"))
+
+	if pkg.Name != "a" || pkg.Path != "packages/a" || pkg.TagStyle != AtTagStyle {
+		t.Errorf("unexpected package: %+v", pkg)
+	}
+
+	sort.Strings(pkg.Dependencies)
+	if !reflect.DeepEqual(pkg.Dependencies, []string{"b", "c"}) {
+		t.Errorf("unexpected dependencies: %v", pkg.Dependencies)
+	}
+}
+
+func TestSortPackages(t *testing.T) {
+	a := &Package{Name: "a", Dependencies: []string{"b", "external"}}
+	b := &Package{Name: "b", Dependencies: []string{"c"}}
+	c := &Package{Name: "c", Dependencies: []string{}}
+	d := &Package{Name: "d", Dependencies: []string{"a", "c"}}
+
+	cases := []struct {
+		packages []*Package
+		expected []*Package
+	}{
+		{[]*Package{a, b, c, d}, []*Package{c, b, a, d}},
+		{[]*Package{d, c, b, a}, []*Package{c, b, a, d}},
+		{[]*Package{c, d}, []*Package{c, d}},
+	}
+
+	for _, tc := range cases {
+		if sorted := SortPackages(tc.packages); !reflect.DeepEqual(sorted, tc.expected) {
+			t.Errorf("unexpected order for %v: %v", packageNames(tc.packages), packageNames(sorted))
+		}
+	}
+}
+
+func packageNames(packages []*Package) []string {
+	names := make([]string, len(packages))
+
+	for i, pkg := range packages {
+		names[i] = pkg.Name
+	}
+
+	return names
+}